@@ -0,0 +1,59 @@
+package hertz
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-orb/go-orb/client"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/log"
+	"github.com/go-orb/plugins/client/orb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-orb/plugins-experimental/server/hertz"
+)
+
+const limitEndpoint = "/test.Limit/Echo"
+
+func setupBodyLimitServer(t *testing.T) (string, log.Logger) {
+	t.Helper()
+
+	ep, logger := setupTestServer(t, func(s *hertz.Server) {
+		s.Router().POST(limitEndpoint, hertz.NewGRPCHandler(s, echoMsg, "test.Limit", "Echo"))
+	}, hertz.WithInsecure(), hertz.WithMaxRequestBodyBytes(1024))
+
+	return ep.Address(), logger
+}
+
+// The limits get tested by the server, the transport maps the 413 response.
+func TestBodyLimit(t *testing.T) {
+	address, logger := setupBodyLimitServer(t)
+
+	cfg := orb.NewConfig()
+
+	tt, err := NewHTTPTransport(logger, &cfg)
+	require.NoError(t, err)
+
+	request := func(ctx context.Context, text string) error {
+		return tt.Request(
+			ctx,
+			client.RequestInfos{Service: "test.limit", Endpoint: limitEndpoint, Address: address},
+			&streamMsg{Text: text},
+			&streamMsg{},
+			&client.CallOptions{ContentType: codecs.MimeJSON},
+		)
+	}
+
+	ctx := context.Background()
+
+	require.NoError(t, request(ctx, "small"))
+	require.ErrorIs(t, request(ctx, strings.Repeat("a", 2048)), ErrRequestEntityTooLarge)
+
+	// The body gets compressed before it's sent, the server limits the decompressed body.
+	err = request(WithCompression(ctx, CompressionGzip), strings.Repeat("a", 8*1024))
+	require.ErrorIs(t, err, ErrRequestEntityTooLarge)
+
+	// The connection of a rejected body gets closed, the next request uses a new one.
+	require.NoError(t, request(ctx, "again"))
+}
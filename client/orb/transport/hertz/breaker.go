@@ -0,0 +1,455 @@
+package hertz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/client"
+	"github.com/go-orb/go-orb/registry"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrCircuitOpen is returned without sending the request when the circuit breaker
+// of the node is open. Its code is 503 so retries and node selection treat it like
+// an unavailable node, but it's its own orberrors value: errors.Is matches it and
+// not orberrors.ErrUnavailable, so callers can tell it from a 503 of the node.
+var ErrCircuitOpen = orberrors.New(consts.StatusServiceUnavailable, "circuit breaker open") //nolint:gochecknoglobals
+
+var _ prometheus.Collector = (*CircuitBreakers)(nil)
+
+// CircuitState is the state of the circuit breaker of a node.
+type CircuitState int
+
+// Circuit breaker states.
+const (
+	// CircuitClosed lets all requests through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails requests fast with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets BreakerPolicy.HalfOpenProbes requests through to probe the node.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerPolicy configures when the circuit breaker of a node trips and recovers.
+//
+// Connection errors, timeouts and 5xx responses count as failures, other
+// responses as successes. Requests canceled by the caller don't count.
+type BreakerPolicy struct {
+	// ConsecutiveFailures trips the breaker after this many failures in a row, 0 disables it.
+	ConsecutiveFailures int
+
+	// FailureRate trips the breaker when this fraction of the requests
+	// in the Window failed, between 0 and 1, 0 disables it.
+	FailureRate float64
+
+	// Window is the duration over which the FailureRate gets measured.
+	Window time.Duration
+
+	// MinRequests is the number of requests in the Window before the FailureRate applies.
+	MinRequests int
+
+	// Cooldown is how long the breaker stays open before it probes the node.
+	Cooldown time.Duration
+
+	// HalfOpenProbes is the number of probe requests, the breaker
+	// closes when all succeeded and opens again on the first failure.
+	HalfOpenProbes int
+
+	// IdleTimeout removes the breaker of a node and its metrics after this long
+	// without requests, so nodes which left the registry don't pile up. Open
+	// breakers get removed once their Cooldown is over, 0 keeps them.
+	IdleTimeout time.Duration
+}
+
+// DefaultBreakerPolicy returns a policy which trips after 5 consecutive failures
+// or when half of at least 20 requests in 10 seconds failed, it probes after 5 seconds.
+// Breakers of nodes without requests for 5 minutes get removed.
+func DefaultBreakerPolicy() BreakerPolicy {
+	return BreakerPolicy{
+		ConsecutiveFailures: 5,
+		FailureRate:         0.5,
+		Window:              10 * time.Second,
+		MinRequests:         20,
+		Cooldown:            5 * time.Second,
+		HalfOpenProbes:      1,
+		IdleTimeout:         5 * time.Minute,
+	}
+}
+
+// breaker is the circuit breaker of a single node.
+type breaker struct {
+	state CircuitState
+
+	// generation changes with the state, results of requests which
+	// were allowed in an earlier generation get ignored.
+	generation uint64
+
+	consecutive int
+
+	windowStart time.Time
+	requests    int
+	failures    int
+
+	openedAt time.Time
+
+	probes    int
+	successes int
+
+	// inFlight are the allowed requests which aren't done yet, lastUsed
+	// is the time of the last one, idle breakers get removed.
+	inFlight int
+	lastUsed time.Time
+}
+
+// CircuitBreakers holds a circuit breaker per node address, create it with
+// NewCircuitBreakers and pass it to the transports with WithCircuitBreakers.
+//
+// It's a prometheus.Collector of the hertz_client_circuit_state and
+// hertz_client_circuit_transitions_total metrics, and its Selector lets
+// the orb client skip nodes with an open breaker.
+type CircuitBreakers struct {
+	policy BreakerPolicy
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+
+	// lastEvict is the time of the last removal of idle breakers.
+	lastEvict time.Time
+
+	// now is replaced by tests.
+	now func() time.Time
+
+	state       *prometheus.GaugeVec
+	transitions *prometheus.CounterVec
+}
+
+// NewCircuitBreakers creates the circuit breakers with the policy.
+func NewCircuitBreakers(policy BreakerPolicy) *CircuitBreakers {
+	return &CircuitBreakers{
+		policy:   policy,
+		breakers: make(map[string]*breaker),
+		now:      time.Now,
+		state: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "hertz_client_circuit_state",
+			Help: "State of the circuit breaker of a node, 0 closed, 1 open and 2 half-open.",
+		}, []string{"address"}),
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hertz_client_circuit_transitions_total",
+			Help: "Number of times the circuit breaker of a node changed into the state.",
+		}, []string{"address", "state"}),
+	}
+}
+
+// WithCircuitBreakers guards the requests of the transport with the circuit breakers,
+// they can be shared between transports and clients.
+func WithCircuitBreakers(b *CircuitBreakers) TransportOption {
+	return func(t *Transport) {
+		t.breakers = b
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (b *CircuitBreakers) Describe(ch chan<- *prometheus.Desc) {
+	b.state.Describe(ch)
+	b.transitions.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (b *CircuitBreakers) Collect(ch chan<- prometheus.Metric) {
+	b.state.Collect(ch)
+	b.transitions.Collect(ch)
+}
+
+// State returns the state of the breaker of the node address.
+func (b *CircuitBreakers) State(address string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br, ok := b.breakers[address]
+	if !ok {
+		return CircuitClosed
+	}
+
+	// An open breaker whose cooldown is over lets the next request probe.
+	if br.state == CircuitOpen && !b.now().Before(br.openedAt.Add(b.policy.Cooldown)) {
+		return CircuitHalfOpen
+	}
+
+	return br.state
+}
+
+// Selector wraps next, it removes the nodes with an open breaker before next
+// selects one of them. When all nodes are open next gets all of them, the
+// request then fails fast with ErrCircuitOpen.
+//
+//	breakers := hertz.NewCircuitBreakers(hertz.DefaultBreakerPolicy())
+//	client.WithClientSelector(breakers.Selector(client.SelectRandomNode))
+func (b *CircuitBreakers) Selector(next client.SelectorFunc) client.SelectorFunc {
+	return func(
+		ctx context.Context,
+		service string,
+		nodes []registry.ServiceNode,
+		preferredTransports []string,
+		anyTransport bool,
+	) (registry.ServiceNode, error) {
+		closed := make([]registry.ServiceNode, 0, len(nodes))
+
+		for _, node := range nodes {
+			if b.State(node.Address) != CircuitOpen {
+				closed = append(closed, node)
+			}
+		}
+
+		if len(closed) == 0 {
+			closed = nodes
+		}
+
+		return next(ctx, service, closed, preferredTransports, anyTransport)
+	}
+}
+
+// allow returns whether a request to the address may be sent, and the
+// generation to pass to done. It moves open breakers to half-open after the cooldown.
+func (b *CircuitBreakers) allow(address string) (uint64, CircuitState, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.evictIdle()
+
+	br := b.breaker(address)
+	from := br.state
+
+	switch br.state {
+	case CircuitClosed:
+		br.begin(b.now())
+		return br.generation, from, true
+	case CircuitOpen:
+		if b.now().Before(br.openedAt.Add(b.policy.Cooldown)) {
+			return br.generation, from, false
+		}
+
+		b.transition(address, br, CircuitHalfOpen)
+	case CircuitHalfOpen:
+	}
+
+	if br.probes >= max(b.policy.HalfOpenProbes, 1) {
+		return br.generation, from, false
+	}
+
+	br.probes++
+	br.begin(b.now())
+
+	return br.generation, from, true
+}
+
+// done records the result of a request allowed by allow, it returns the
+// state before and after it.
+func (b *CircuitBreakers) done(address string, generation uint64, failed bool) (CircuitState, CircuitState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br := b.breaker(address)
+	br.inFlight--
+
+	from := br.state
+
+	if br.generation != generation {
+		return from, from
+	}
+
+	switch br.state {
+	case CircuitClosed:
+		b.recordClosed(address, br, failed)
+	case CircuitHalfOpen:
+		if failed {
+			b.open(address, br)
+			break
+		}
+
+		br.successes++
+		if br.successes >= max(b.policy.HalfOpenProbes, 1) {
+			b.transition(address, br, CircuitClosed)
+		}
+	case CircuitOpen:
+	}
+
+	return from, br.state
+}
+
+// release gives back a half-open probe whose request didn't count.
+func (b *CircuitBreakers) release(address string, generation uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br := b.breaker(address)
+	br.inFlight--
+
+	if br.generation == generation && br.state == CircuitHalfOpen && br.probes > 0 {
+		br.probes--
+	}
+}
+
+// recordClosed counts the result of a closed breaker and trips it.
+func (b *CircuitBreakers) recordClosed(address string, br *breaker, failed bool) {
+	now := b.now()
+	if b.policy.Window > 0 && now.Sub(br.windowStart) > b.policy.Window {
+		br.windowStart = now
+		br.requests = 0
+		br.failures = 0
+	}
+
+	br.requests++
+
+	if !failed {
+		br.consecutive = 0
+		return
+	}
+
+	br.failures++
+	br.consecutive++
+
+	switch {
+	case b.policy.ConsecutiveFailures > 0 && br.consecutive >= b.policy.ConsecutiveFailures:
+		b.open(address, br)
+	case b.policy.FailureRate > 0 && br.requests >= max(b.policy.MinRequests, 1) &&
+		float64(br.failures)/float64(br.requests) >= b.policy.FailureRate:
+		b.open(address, br)
+	}
+}
+
+func (b *CircuitBreakers) open(address string, br *breaker) {
+	br.openedAt = b.now()
+	b.transition(address, br, CircuitOpen)
+}
+
+// transition changes the state and resets the counters of the breaker.
+func (b *CircuitBreakers) transition(address string, br *breaker, state CircuitState) {
+	br.state = state
+	br.generation++
+	br.consecutive = 0
+	br.windowStart = b.now()
+	br.requests = 0
+	br.failures = 0
+	br.probes = 0
+	br.successes = 0
+
+	b.state.WithLabelValues(address).Set(float64(state))
+	b.transitions.WithLabelValues(address, state.String()).Inc()
+}
+
+func (b *CircuitBreakers) breaker(address string) *breaker {
+	br, ok := b.breakers[address]
+	if !ok {
+		br = &breaker{windowStart: b.now(), lastUsed: b.now()}
+		b.breakers[address] = br
+
+		b.state.WithLabelValues(address).Set(float64(CircuitClosed))
+	}
+
+	return br
+}
+
+// begin counts a request allowed at now.
+func (br *breaker) begin(now time.Time) {
+	br.inFlight++
+	br.lastUsed = now
+}
+
+// evictIdle removes the breakers without requests for the IdleTimeout and their
+// metrics, open breakers once their cooldown is over. It checks at most once per IdleTimeout.
+func (b *CircuitBreakers) evictIdle() {
+	idle := b.policy.IdleTimeout
+	now := b.now()
+
+	if idle <= 0 || now.Sub(b.lastEvict) < idle {
+		return
+	}
+
+	b.lastEvict = now
+
+	for address, br := range b.breakers {
+		if br.inFlight > 0 || now.Sub(br.lastUsed) < idle {
+			continue
+		}
+
+		if br.state != CircuitClosed && now.Before(br.openedAt.Add(b.policy.Cooldown)) {
+			continue
+		}
+
+		delete(b.breakers, address)
+
+		b.state.DeleteLabelValues(address)
+
+		for _, state := range []CircuitState{CircuitClosed, CircuitOpen, CircuitHalfOpen} {
+			b.transitions.DeleteLabelValues(address, state.String())
+		}
+	}
+}
+
+// breakerFailure returns whether the attempt counts as a failure of the node,
+// and false for counted when the caller canceled it.
+func breakerFailure(ctx context.Context, err error, res *attemptResult) (bool, bool) {
+	if err == nil {
+		return false, true
+	}
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return false, false
+	}
+
+	return res.dialFailed || res.connFailed || res.status >= 500, true
+}
+
+// guard sends the request through the circuit breaker of infos.Address.
+func (t *Transport) guard(ctx context.Context, infos client.RequestInfos, res *attemptResult, send func() error) error {
+	if t.breakers == nil {
+		return send()
+	}
+
+	generation, before, ok := t.breakers.allow(infos.Address)
+	if !ok {
+		return ErrCircuitOpen.Wrap(fmt.Errorf("node '%s'", infos.Address))
+	}
+
+	if before == CircuitOpen {
+		t.logger.Info("Circuit breaker half-open, probing node", "address", infos.Address)
+	}
+
+	err := send()
+
+	failed, counted := breakerFailure(ctx, err, res)
+	if !counted {
+		t.breakers.release(infos.Address, generation)
+		return err
+	}
+
+	from, to := t.breakers.done(infos.Address, generation, failed)
+
+	switch {
+	case from == to:
+	case to == CircuitOpen:
+		t.logger.Warn("Circuit breaker opened", "address", infos.Address, "from", from.String(), "error", err)
+	case to == CircuitClosed:
+		t.logger.Info("Circuit breaker closed", "address", infos.Address)
+	}
+
+	return err
+}
@@ -0,0 +1,256 @@
+package hertz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/client"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/registry"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/go-orb/plugins/client/orb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced clock for the breakers.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestBreakers(policy BreakerPolicy) (*CircuitBreakers, *fakeClock) {
+	clock := &fakeClock{now: time.Now()}
+
+	b := NewCircuitBreakers(policy)
+	b.now = clock.Now
+
+	return b, clock
+}
+
+// call runs a request through the breaker of the address, it returns false when it wasn't allowed.
+func (b *CircuitBreakers) call(address string, failed bool) bool {
+	generation, _, ok := b.allow(address)
+	if !ok {
+		return false
+	}
+
+	b.done(address, generation, failed)
+
+	return true
+}
+
+func metricValue(t *testing.T, m prometheus.Metric) float64 {
+	t.Helper()
+
+	pb := &dto.Metric{}
+	require.NoError(t, m.Write(pb))
+
+	if pb.GetGauge() != nil {
+		return pb.GetGauge().GetValue()
+	}
+
+	return pb.GetCounter().GetValue()
+}
+
+func TestBreakerConsecutiveFailures(t *testing.T) {
+	b, clock := newTestBreakers(BreakerPolicy{ConsecutiveFailures: 3, Cooldown: time.Second, HalfOpenProbes: 1})
+
+	const address = "127.0.0.1:8080"
+
+	require.True(t, b.call(address, true))
+	require.True(t, b.call(address, true))
+	require.True(t, b.call(address, false))
+	require.True(t, b.call(address, true))
+	require.True(t, b.call(address, true))
+	require.Equal(t, CircuitClosed, b.State(address))
+
+	require.True(t, b.call(address, true))
+	require.Equal(t, CircuitOpen, b.State(address))
+	require.False(t, b.call(address, false))
+
+	// A failed probe opens the breaker again.
+	clock.now = clock.now.Add(time.Second)
+	require.Equal(t, CircuitHalfOpen, b.State(address))
+	require.True(t, b.call(address, true))
+	require.Equal(t, CircuitOpen, b.State(address))
+
+	clock.now = clock.now.Add(time.Second)
+
+	generation, _, ok := b.allow(address)
+	require.True(t, ok)
+
+	// Only a single probe is in flight.
+	require.False(t, b.call(address, false))
+
+	b.done(address, generation, false)
+	require.Equal(t, CircuitClosed, b.State(address))
+
+	require.InDelta(t, 2, metricValue(t, b.transitions.WithLabelValues(address, "open")), 0)
+	require.InDelta(t, 1, metricValue(t, b.transitions.WithLabelValues(address, "closed")), 0)
+	require.InDelta(t, float64(CircuitClosed), metricValue(t, b.state.WithLabelValues(address)), 0)
+}
+
+func TestBreakerFailureRate(t *testing.T) {
+	b, clock := newTestBreakers(BreakerPolicy{FailureRate: 0.5, Window: time.Second, MinRequests: 4, Cooldown: time.Second})
+
+	const address = "127.0.0.1:8080"
+
+	// The window resets before the minimum number of requests.
+	require.True(t, b.call(address, true))
+	require.True(t, b.call(address, true))
+	require.True(t, b.call(address, false))
+
+	clock.now = clock.now.Add(2 * time.Second)
+
+	require.True(t, b.call(address, true))
+	require.True(t, b.call(address, false))
+	require.True(t, b.call(address, false))
+	require.Equal(t, CircuitClosed, b.State(address))
+
+	require.True(t, b.call(address, true))
+	require.Equal(t, CircuitOpen, b.State(address))
+}
+
+func TestBreakerStaleResults(t *testing.T) {
+	b, _ := newTestBreakers(BreakerPolicy{ConsecutiveFailures: 1, Cooldown: time.Minute})
+
+	const address = "127.0.0.1:8080"
+
+	generation, _, ok := b.allow(address)
+	require.True(t, ok)
+
+	require.True(t, b.call(address, true))
+	require.Equal(t, CircuitOpen, b.State(address))
+
+	// The success of a request sent before the breaker opened doesn't count.
+	b.done(address, generation, false)
+	require.Equal(t, CircuitOpen, b.State(address))
+}
+
+func TestBreakerEvictIdle(t *testing.T) {
+	b, clock := newTestBreakers(BreakerPolicy{ConsecutiveFailures: 1, Cooldown: 2 * time.Minute, IdleTimeout: time.Minute})
+
+	const (
+		closed   = "127.0.0.1:8080"
+		open     = "127.0.0.1:8081"
+		inFlight = "127.0.0.1:8082"
+	)
+
+	require.True(t, b.call(closed, false))
+	require.True(t, b.call(open, true))
+
+	generation, _, ok := b.allow(inFlight)
+	require.True(t, ok)
+
+	require.Equal(t, 3, testutil.CollectAndCount(b, "hertz_client_circuit_state"))
+
+	// The open breaker waits for its cooldown, the request in flight keeps its breaker.
+	clock.now = clock.now.Add(59 * time.Second)
+	require.True(t, b.call("127.0.0.1:8083", false))
+
+	clock.now = clock.now.Add(time.Second)
+	require.True(t, b.call("127.0.0.1:8083", false))
+	require.Len(t, b.breakers, 3)
+	require.NotContains(t, b.breakers, closed)
+	require.Contains(t, b.breakers, open)
+	require.Equal(t, 3, testutil.CollectAndCount(b, "hertz_client_circuit_state"))
+
+	b.done(inFlight, generation, false)
+
+	clock.now = clock.now.Add(2 * time.Minute)
+	require.True(t, b.call("127.0.0.1:8083", false))
+	require.Len(t, b.breakers, 1)
+	require.Equal(t, 1, testutil.CollectAndCount(b, "hertz_client_circuit_state"))
+	require.Equal(t, 0, testutil.CollectAndCount(b, "hertz_client_circuit_transitions_total"))
+}
+
+func TestBreakerSelector(t *testing.T) {
+	b, _ := newTestBreakers(BreakerPolicy{ConsecutiveFailures: 1, Cooldown: time.Minute})
+
+	open := registry.ServiceNode{Name: "test", Node: "open", Address: "127.0.0.1:8080", Scheme: "hertzhttp"}
+	closed := registry.ServiceNode{Name: "test", Node: "closed", Address: "127.0.0.1:8081", Scheme: "hertzhttp"}
+	nodes := []registry.ServiceNode{open, closed}
+
+	require.True(t, b.call(open.Address, true))
+
+	selector := b.Selector(client.SelectRandomNode)
+
+	for range 20 {
+		node, err := selector(context.Background(), "test", nodes, []string{"hertzhttp"}, false)
+		require.NoError(t, err)
+		require.Equal(t, closed, node)
+	}
+
+	// Without a closed node the selector falls back to all of them.
+	require.True(t, b.call(closed.Address, true))
+
+	node, err := selector(context.Background(), "test", nodes, []string{"hertzhttp"}, false)
+	require.NoError(t, err)
+	require.Contains(t, nodes, node)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	routes := map[string]*flakyRoute{
+		"/test.Breaker/Unavailable": {failures: 3, code: consts.StatusServiceUnavailable},
+		"/test.Breaker/BadRequest":  {failures: 10, code: consts.StatusBadRequest},
+	}
+
+	address, logger := setupRetryServer(t, routes)
+
+	breakers, clock := newTestBreakers(BreakerPolicy{ConsecutiveFailures: 3, Cooldown: time.Second, HalfOpenProbes: 1})
+
+	cfg := orb.NewConfig()
+
+	tt, err := NewHTTPTransport(logger, &cfg, WithCircuitBreakers(breakers))
+	require.NoError(t, err)
+
+	request := func(endpoint string) error {
+		return tt.Request(
+			context.Background(),
+			client.RequestInfos{Service: "test.retry", Endpoint: endpoint, Address: address},
+			&streamMsg{Text: "hello"},
+			&streamMsg{},
+			&client.CallOptions{ContentType: codecs.MimeJSON},
+		)
+	}
+
+	// Client errors don't trip the breaker.
+	for range 5 {
+		require.ErrorIs(t, request("/test.Breaker/BadRequest"), orberrors.ErrBadRequest)
+	}
+
+	require.Equal(t, CircuitClosed, breakers.State(address))
+
+	// Errors of the node aren't ErrCircuitOpen.
+	for range 3 {
+		err := request("/test.Breaker/Unavailable")
+		require.ErrorIs(t, err, orberrors.ErrUnavailable)
+		require.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+
+	require.Equal(t, CircuitOpen, breakers.State(address))
+
+	// Open breakers fail fast without sending the request.
+	err = request("/test.Breaker/Unavailable")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.NotErrorIs(t, err, orberrors.ErrUnavailable)
+
+	orbe, ok := orberrors.As(err)
+	require.True(t, ok)
+	require.Equal(t, consts.StatusServiceUnavailable, orbe.Code)
+	require.Equal(t, int32(3), routes["/test.Breaker/Unavailable"].calls.Load())
+
+	// The probe after the cooldown succeeds and closes the breaker.
+	clock.now = clock.now.Add(time.Second)
+
+	require.NoError(t, request("/test.Breaker/Unavailable"))
+	require.Equal(t, CircuitClosed, breakers.State(address))
+}
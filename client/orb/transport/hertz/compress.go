@@ -0,0 +1,248 @@
+package hertz
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/client"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression algorithms, the names are the HTTP content codings.
+const (
+	CompressionGzip   = "gzip"
+	CompressionBrotli = "br"
+	CompressionZstd   = "zstd"
+
+	CompressionIdentity = "identity"
+)
+
+// compressionKey is the context key of WithCompression.
+type compressionKey struct{}
+
+// ErrUnsupportedEncoding is returned for unknown compression algorithms.
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// zstdEncoders caches a zstd encoder per level, EncodeAll is safe for concurrent use.
+var zstdEncoders sync.Map //nolint:gochecknoglobals
+
+// isCompression returns whether we support the compression algorithm.
+func isCompression(algorithm string) bool {
+	switch algorithm {
+	case CompressionGzip, CompressionBrotli, CompressionZstd:
+		return true
+	default:
+		return false
+	}
+}
+
+// compress compresses data with the algorithm, level 0 is the algorithms
+// default, levels above the algorithms maximum get capped.
+func compress(algorithm string, data []byte, level int) ([]byte, error) {
+	switch algorithm {
+	case CompressionZstd:
+		enc, err := zstdEncoder(level)
+		if err != nil {
+			return nil, err
+		}
+
+		return enc.EncodeAll(data, nil), nil
+	case CompressionGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+
+		buf := bytes.NewBuffer(nil)
+
+		w, err := gzip.NewWriterLevel(buf, min(level, gzip.BestCompression))
+		if err != nil {
+			return nil, err
+		}
+
+		return writeCompressed(buf, w, data)
+	case CompressionBrotli:
+		if level == 0 {
+			level = brotli.DefaultCompression
+		}
+
+		buf := bytes.NewBuffer(nil)
+
+		return writeCompressed(buf, brotli.NewWriterLevel(buf, min(level, brotli.BestCompression)), data)
+	default:
+		return nil, fmt.Errorf("%w: '%s'", ErrUnsupportedEncoding, algorithm)
+	}
+}
+
+func writeCompressed(buf *bytes.Buffer, w io.WriteCloser, data []byte) ([]byte, error) {
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func zstdEncoder(level int) (*zstd.Encoder, error) {
+	if enc, ok := zstdEncoders.Load(level); ok {
+		return enc.(*zstd.Encoder), nil //nolint:forcetypeassert
+	}
+
+	zlevel := zstd.SpeedDefault
+	if level > 0 {
+		zlevel = zstd.EncoderLevelFromZstd(level)
+	}
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zlevel))
+	if err != nil {
+		return nil, err
+	}
+
+	actual, _ := zstdEncoders.LoadOrStore(level, enc)
+
+	return actual.(*zstd.Encoder), nil //nolint:forcetypeassert
+}
+
+// decompress decompresses data with the algorithm, maxSize limits
+// the size of the decompressed data when it's above 0.
+func decompress(algorithm string, data []byte, maxSize int) ([]byte, error) {
+	var r io.Reader
+
+	switch algorithm {
+	case CompressionGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close() //nolint:errcheck
+
+		r = gr
+	case CompressionBrotli:
+		r = brotli.NewReader(bytes.NewReader(data))
+	case CompressionZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(data), zstdDecoderOptions(maxSize)...)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+
+		r = zr
+	default:
+		return nil, fmt.Errorf("%w: '%s'", ErrUnsupportedEncoding, algorithm)
+	}
+
+	if maxSize > 0 {
+		r = io.LimitReader(r, int64(maxSize)+1)
+	}
+
+	out, err := io.ReadAll(r)
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) ||
+		(maxSize > 0 && len(out) > maxSize) {
+		return nil, orberrors.HTTP(consts.StatusRequestEntityTooLarge).Wrap(ErrMessageTooLarge)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// zstdDecoderOptions limits the window and the decoded size of the zstd decoder
+// to maxSize when it's above 0, else a frame may make it allocate up to the
+// maximum window size before the LimitReader steps in.
+func zstdDecoderOptions(maxSize int) []zstd.DOption {
+	opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+	if maxSize > 0 {
+		opts = append(opts,
+			zstd.WithDecoderMaxMemory(uint64(maxSize)),                          //nolint:gosec
+			zstd.WithDecoderMaxWindow(max(uint64(maxSize), zstd.MinWindowSize)), //nolint:gosec
+		)
+	}
+
+	return opts
+}
+
+// WithCompression returns a context which makes the hertz transports compress
+// the request bodies of its calls with the algorithm, use CompressionIdentity
+// to also ask the server for uncompressed responses.
+//
+// Responses get decompressed without it.
+func WithCompression(ctx context.Context, algorithm string) context.Context {
+	return context.WithValue(ctx, compressionKey{}, algorithm)
+}
+
+// requestCompression returns the algorithm set by WithCompression.
+func requestCompression(ctx context.Context) string {
+	algorithm, _ := ctx.Value(compressionKey{}).(string) //nolint:errcheck
+	return strings.ToLower(algorithm)
+}
+
+// acceptEncoding returns the Accept-Encoding header, the requests algorithm is preferred.
+func acceptEncoding(algorithm string) string {
+	if algorithm == CompressionIdentity {
+		return CompressionIdentity
+	}
+
+	algorithms := []string{CompressionZstd, CompressionBrotli, CompressionGzip}
+	if isCompression(algorithm) {
+		algorithms = slices.DeleteFunc(algorithms, func(a string) bool { return a == algorithm })
+		algorithms = append([]string{algorithm}, algorithms...)
+	}
+
+	return strings.Join(algorithms, ", ")
+}
+
+// compressRequest compresses the request body with the algorithm set by WithCompression.
+func compressRequest(ctx context.Context, hReq *protocol.Request, body []byte) error {
+	algorithm := requestCompression(ctx)
+
+	hReq.Header.Set(consts.HeaderAcceptEncoding, acceptEncoding(algorithm))
+
+	if algorithm == "" || algorithm == CompressionIdentity {
+		hReq.SetBody(body)
+		return nil
+	}
+
+	out, err := compress(algorithm, body, 0)
+	if err != nil {
+		return orberrors.ErrBadRequest.Wrap(err)
+	}
+
+	hReq.SetBody(out)
+	hReq.Header.Set(consts.HeaderContentEncoding, algorithm)
+
+	return nil
+}
+
+// responseBody returns the decompressed response body.
+func responseBody(hRes *protocol.Response, opts *client.CallOptions) ([]byte, error) {
+	algorithm := strings.ToLower(string(hRes.Header.Peek(consts.HeaderContentEncoding)))
+	if algorithm == "" || algorithm == CompressionIdentity {
+		return hRes.Body(), nil
+	}
+
+	body, err := decompress(algorithm, hRes.Body(), opts.MaxCallRecvMsgSize)
+	if err != nil {
+		if orbe, ok := orberrors.As(err); ok {
+			return nil, orbe
+		}
+
+		return nil, orberrors.ErrInternalServerError.Wrap(err)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,92 @@
+package hertz
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/client"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/log"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/go-orb/plugins/client/orb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-orb/plugins-experimental/server/hertz"
+)
+
+const compressEndpoint = "/test.Compress/Echo"
+
+var compressionAlgorithms = []string{CompressionGzip, CompressionBrotli, CompressionZstd} //nolint:gochecknoglobals
+
+func echoMsg(_ context.Context, req *streamMsg) (*streamMsg, error) {
+	return req, nil
+}
+
+func setupCompressServer(t *testing.T, algorithm string) (string, log.Logger) {
+	t.Helper()
+
+	ep, logger := setupTestServer(t, func(s *hertz.Server) {
+		s.Router().POST(compressEndpoint, hertz.NewGRPCHandler(s, echoMsg, "test.Compress", "Echo"))
+	}, hertz.WithInsecure(), hertz.WithCompression(algorithm), hertz.WithCompressionMinSize(0))
+
+	return ep.Address(), logger
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	data := []byte(strings.Repeat("go-orb hertz compression ", 100))
+
+	for _, algorithm := range compressionAlgorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			compressed, err := compress(algorithm, data, 0)
+			require.NoError(t, err)
+			require.Less(t, len(compressed), len(data))
+
+			out, err := decompress(algorithm, compressed, 0)
+			require.NoError(t, err)
+			require.Equal(t, data, out)
+
+			_, err = decompress(algorithm, compressed, 10)
+			orbe, ok := orberrors.As(err)
+			require.True(t, ok)
+			require.Equal(t, consts.StatusRequestEntityTooLarge, orbe.Code)
+		})
+	}
+}
+
+func TestCompressUnsupported(t *testing.T) {
+	_, err := compress("lzma", []byte("data"), 0)
+	require.ErrorIs(t, err, ErrUnsupportedEncoding)
+}
+
+func TestAcceptEncoding(t *testing.T) {
+	require.Equal(t, "zstd, br, gzip", acceptEncoding(""))
+	require.Equal(t, "gzip, zstd, br", acceptEncoding(CompressionGzip))
+	require.Equal(t, "identity", acceptEncoding(CompressionIdentity))
+}
+
+func TestCompressRequest(t *testing.T) {
+	text := strings.Repeat("compress me ", 200)
+
+	for _, algorithm := range compressionAlgorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			address, logger := setupCompressServer(t, algorithm)
+
+			cfg := orb.NewConfig()
+
+			tt, err := NewHTTPTransport(logger, &cfg)
+			require.NoError(t, err)
+
+			resp := &streamMsg{}
+			require.NoError(t, tt.Request(
+				WithCompression(context.Background(), algorithm),
+				client.RequestInfos{Service: "test.compress", Endpoint: compressEndpoint, Address: address},
+				&streamMsg{Text: text},
+				resp,
+				&client.CallOptions{ContentType: codecs.MimeJSON},
+			))
+			require.Equal(t, text, resp.Text)
+		})
+	}
+}
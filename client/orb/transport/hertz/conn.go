@@ -0,0 +1,56 @@
+package hertz
+
+import (
+	"crypto/tls"
+	"net"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/network"
+
+	"github.com/go-orb/plugins-experimental/client/orb/transport/hertz/internal/bufconn"
+)
+
+var (
+	_ network.Conn               = (*conn)(nil)
+	_ network.ErrorNormalization = (*conn)(nil)
+	_ network.ConnTLSer          = (*tlsConn)(nil)
+)
+
+// conn is a buffered connection of the pool.
+type conn struct {
+	*bufconn.Conn
+
+	// onClose gets called once the connection got closed.
+	onClose   func()
+	closeOnce sync.Once
+}
+
+func newConn(c net.Conn, readBufferSize, writeBufferSize int, onClose func()) *conn {
+	return &conn{Conn: bufconn.New(c, readBufferSize, writeBufferSize), onClose: onClose}
+}
+
+// Close closes the connection, it's safe to call it multiple times.
+func (c *conn) Close() error {
+	err := c.Conn.Close()
+
+	if c.onClose != nil {
+		c.closeOnce.Do(c.onClose)
+	}
+
+	return err
+}
+
+// tlsConn is a conn over TLS, the HTTP/2 client uses it for ALPN.
+type tlsConn struct {
+	*conn
+
+	tc *tls.Conn
+}
+
+func (c *tlsConn) Handshake() error {
+	return c.tc.Handshake()
+}
+
+func (c *tlsConn) ConnectionState() tls.ConnectionState {
+	return c.tc.ConnectionState()
+}
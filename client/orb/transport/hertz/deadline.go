@@ -0,0 +1,74 @@
+package hertz
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/go-orb/go-orb/util/orberrors"
+)
+
+// timeoutHeader carries the remaining time until the deadline of a request
+// to the server, in the format of grpc-timeout.
+const timeoutHeader = "Orb-Timeout"
+
+// maxTimeoutValue is the maximum value of the timeoutHeader, 8 digits.
+const maxTimeoutValue = 99_999_999
+
+//nolint:gochecknoglobals
+var timeoutUnits = []struct {
+	unit byte
+	d    time.Duration
+}{
+	{'n', time.Nanosecond},
+	{'u', time.Microsecond},
+	{'m', time.Millisecond},
+	{'S', time.Second},
+	{'M', time.Minute},
+	{'H', time.Hour},
+}
+
+// encodeTimeout formats a timeout for the timeoutHeader,
+// it uses the most precise unit and rounds up.
+func encodeTimeout(d time.Duration) string {
+	if d <= 0 {
+		return "0n"
+	}
+
+	for _, u := range timeoutUnits {
+		v := (d + u.d - 1) / u.d
+		if v <= maxTimeoutValue {
+			return strconv.FormatInt(int64(v), 10) + string(u.unit)
+		}
+	}
+
+	return strconv.Itoa(maxTimeoutValue) + "H"
+}
+
+// requestTimeout returns the earlier of the contexts deadline and the timeout,
+// as duration from now. It's 0 when there's neither.
+func requestTimeout(ctx context.Context, timeout time.Duration) (time.Duration, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return timeout, nil
+	}
+
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return 0, orberrors.ErrRequestTimeout.Wrap(context.DeadlineExceeded)
+	}
+
+	if timeout > 0 && timeout < remaining {
+		return timeout, nil
+	}
+
+	return remaining, nil
+}
+
+// setTimeout sends the timeout to the server.
+func setTimeout(hReq *protocol.Request, timeout time.Duration) {
+	if timeout > 0 {
+		hReq.Header.Set(timeoutHeader, encodeTimeout(timeout))
+	}
+}
@@ -0,0 +1,168 @@
+package hertz
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-orb/go-orb/client"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/log"
+	"github.com/go-orb/plugins/client/orb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-orb/plugins-experimental/server/hertz"
+)
+
+const (
+	deadlineEndpoint = "/test.Deadline/Remaining"
+	blockEndpoint    = "/test.Deadline/Block"
+)
+
+// remaining answers with the time left until the deadline of the handler context.
+func remaining(ctx context.Context, _ *streamMsg) (*streamMsg, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return &streamMsg{Text: "none"}, nil
+	}
+
+	return &streamMsg{Text: time.Until(deadline).String()}, nil
+}
+
+func setupDeadlineServer(t *testing.T, canceled chan<- error) (string, log.Logger) {
+	t.Helper()
+
+	block := func(ctx context.Context, _ *streamMsg) (*streamMsg, error) {
+		<-ctx.Done()
+		canceled <- ctx.Err()
+
+		return nil, ctx.Err()
+	}
+
+	ep, logger := setupTestServer(t, func(s *hertz.Server) {
+		s.Router().POST(deadlineEndpoint, hertz.NewGRPCHandler(s, remaining, "test.Deadline", "Remaining"))
+		s.Router().POST(blockEndpoint, hertz.NewGRPCHandler(s, block, "test.Deadline", "Block"))
+	}, hertz.WithInsecure())
+
+	return ep.Address(), logger
+}
+
+func TestEncodeTimeout(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "0n"},
+		{-time.Second, "0n"},
+		{time.Nanosecond, "1n"},
+		{99_999_999 * time.Nanosecond, "99999999n"},
+		{100 * time.Millisecond, "100000u"},
+		{5 * time.Second, "5000000u"},
+		{2 * time.Minute, "120000m"},
+		{100_000_000 * time.Second, "1666667M"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in.String(), func(t *testing.T) {
+			got := encodeTimeout(tt.in)
+			require.Equal(t, tt.want, got)
+
+			d, err := hertz.DecodeTimeout(got)
+			require.NoError(t, err)
+			require.GreaterOrEqual(t, d, tt.in)
+		})
+	}
+}
+
+func TestDecodeTimeoutInvalid(t *testing.T) {
+	for _, v := range []string{"", "1", "S", "-1S", "1x", "123456789S", "1.5S"} {
+		_, err := hertz.DecodeTimeout(v)
+		require.ErrorIs(t, err, hertz.ErrInvalidTimeout, v)
+	}
+}
+
+func TestDeadlinePropagation(t *testing.T) {
+	address, logger := setupDeadlineServer(t, make(chan error, 1))
+
+	cfg := orb.NewConfig()
+
+	tt, err := NewHTTPTransport(logger, &cfg)
+	require.NoError(t, err)
+
+	infos := client.RequestInfos{Service: "test.deadline", Endpoint: deadlineEndpoint, Address: address}
+
+	// The deadline of the context is earlier than the request timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	resp := &streamMsg{}
+	require.NoError(t, tt.Request(ctx, infos, &streamMsg{}, resp,
+		&client.CallOptions{ContentType: codecs.MimeJSON, RequestTimeout: time.Minute}))
+
+	left, err := time.ParseDuration(resp.Text)
+	require.NoError(t, err)
+	require.LessOrEqual(t, left, 2*time.Second)
+	require.Greater(t, left, time.Second)
+
+	// The request timeout is earlier than the deadline of the context.
+	resp = &streamMsg{}
+	require.NoError(t, tt.Request(context.Background(), infos, &streamMsg{}, resp,
+		&client.CallOptions{ContentType: codecs.MimeJSON, RequestTimeout: 3 * time.Second}))
+
+	left, err = time.ParseDuration(resp.Text)
+	require.NoError(t, err)
+	require.LessOrEqual(t, left, 3*time.Second)
+	require.Greater(t, left, 2*time.Second)
+}
+
+// sendRaw sends a HTTP/1.1 request to the block endpoint without waiting for the response.
+func sendRaw(t *testing.T, address string, header string) net.Conn {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+
+	body := "{}"
+	_, err = fmt.Fprintf(conn,
+		"POST %s HTTP/1.1\r\nHost: %s\r\nContent-Type: application/json\r\nContent-Length: %d\r\n%s\r\n%s",
+		blockEndpoint, address, len(body), header, body,
+	)
+	require.NoError(t, err)
+
+	return conn
+}
+
+func TestDeadlineExceeded(t *testing.T) {
+	canceled := make(chan error, 1)
+	address, _ := setupDeadlineServer(t, canceled)
+
+	conn := sendRaw(t, address, "Orb-Timeout: 200m\r\n")
+	defer conn.Close() //nolint:errcheck
+
+	select {
+	case err := <-canceled:
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the handler context didn't get canceled")
+	}
+}
+
+func TestDeadlineCancelOnDisconnect(t *testing.T) {
+	canceled := make(chan error, 1)
+	address, _ := setupDeadlineServer(t, canceled)
+
+	conn := sendRaw(t, address, "")
+
+	// Give the server time to call the handler.
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, conn.Close())
+
+	select {
+	case err := <-canceled:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the handler context didn't get canceled")
+	}
+}
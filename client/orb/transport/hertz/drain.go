@@ -0,0 +1,117 @@
+package hertz
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrTransportStopped is returned for requests after the transport got stopped.
+	ErrTransportStopped = errors.New("hertz transport is stopped")
+	// ErrStopTimeout is returned by Stop when requests were still in flight once its context was done.
+	ErrStopTimeout = errors.New("timeout while stopping the hertz transport")
+)
+
+// drainer counts the requests in flight, so Stop can wait for them.
+type drainer struct {
+	mu  sync.Mutex
+	gen *drainGeneration
+}
+
+// drainGeneration counts the requests of one start of the transport, requests
+// aborted by a previous Stop keep ending on their own generation.
+type drainGeneration struct {
+	mu       sync.Mutex
+	inFlight int
+	draining bool
+
+	// idle gets closed once draining and no request is in flight.
+	idle chan struct{}
+}
+
+func newDrainer() *drainer {
+	d := &drainer{}
+	d.reset()
+
+	return d
+}
+
+// reset starts a new generation for a new start of the transport.
+func (d *drainer) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.gen = &drainGeneration{idle: make(chan struct{})}
+}
+
+// current returns the generation of the running transport.
+func (d *drainer) current() *drainGeneration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.gen
+}
+
+// begin adds a request, it returns nil once the transport got stopped.
+// The request has to be removed with end of the returned generation.
+func (d *drainer) begin() *drainGeneration {
+	g := d.current()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.draining {
+		return nil
+	}
+
+	g.inFlight++
+
+	return g
+}
+
+// wait rejects new requests and waits for the requests in flight,
+// it returns how many are left when ctx is done.
+func (d *drainer) wait(ctx context.Context) int {
+	g := d.current()
+
+	select {
+	case <-g.start():
+		return 0
+	case <-ctx.Done():
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.inFlight
+}
+
+// end removes a request added by begin.
+func (g *drainGeneration) end() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.inFlight--
+
+	if g.draining && g.inFlight == 0 {
+		close(g.idle)
+	}
+}
+
+// start rejects new requests, the returned channel gets closed once
+// the requests in flight are done.
+func (g *drainGeneration) start() <-chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.draining {
+		g.draining = true
+
+		if g.inFlight == 0 {
+			close(g.idle)
+		}
+	}
+
+	return g.idle
+}
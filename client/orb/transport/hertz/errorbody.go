@@ -0,0 +1,111 @@
+package hertz
+
+import (
+	"encoding/json"
+
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// maxErrorDepth limits the number of wrapped errors decoded from a response.
+const maxErrorDepth = 16
+
+// ErrRequestEntityTooLarge is returned when the server rejected the request body or
+// a stream message as too large, it wraps the error of the server.
+var ErrRequestEntityTooLarge = orberrors.New(consts.StatusRequestEntityTooLarge, "request entity too large") //nolint:gochecknoglobals
+
+// errorBody is the body of error responses written by the hertz server,
+// it carries the chain of wrapped errors. Code is only set for orberrors.
+type errorBody struct {
+	Code    int        `json:"code,omitempty"`
+	Message string     `json:"message"`
+	Wrapped *errorBody `json:"wrapped,omitempty"`
+}
+
+// remoteError is an error received from the server which isn't an orberror.
+type remoteError struct {
+	message string
+	wrapped error
+}
+
+func (e *remoteError) Error() string {
+	return e.message
+}
+
+func (e *remoteError) Unwrap() error {
+	return e.wrapped
+}
+
+// toError converts the errorBody back into the chain of errors,
+// errors.Is works for orberrors as they compare by code and message.
+func (b *errorBody) toError(depth int) error {
+	var wrapped error
+	if b.Wrapped != nil && depth < maxErrorDepth {
+		wrapped = b.Wrapped.toError(depth + 1)
+	}
+
+	if b.Code != 0 {
+		return &orberrors.Error{Code: b.Code, Message: b.Message, Wrapped: wrapped}
+	}
+
+	return &remoteError{message: b.Message, wrapped: wrapped}
+}
+
+// unmarshalErrorBody decodes an errorBody with the codec for the content type,
+// proto codecs get it as google.protobuf.Struct.
+func unmarshalErrorBody(contentType string, data []byte) (*errorBody, bool) {
+	body := &errorBody{}
+
+	if codec, err := codecs.GetDecoder(contentType, body); err == nil {
+		if err := codec.Unmarshal(data, body); err == nil {
+			return body, true
+		}
+	}
+
+	s := &structpb.Struct{}
+	if codec, err := codecs.GetDecoder(contentType, s); err == nil {
+		if err := codec.Unmarshal(data, s); err == nil {
+			data, err = json.Marshal(s.AsMap())
+			if err != nil {
+				return nil, false
+			}
+		}
+	}
+
+	if err := json.Unmarshal(data, body); err != nil {
+		return nil, false
+	}
+
+	return body, true
+}
+
+// decodeError converts an error response into an orberror,
+// it falls back to the status code if the body isn't an errorBody.
+func decodeError(status int, contentType string, data []byte) *orberrors.Error {
+	body, ok := unmarshalErrorBody(contentType, data)
+	if !ok || body.Message == "" {
+		return statusError(status)
+	}
+
+	err := body.toError(0)
+	if status == consts.StatusRequestEntityTooLarge {
+		return ErrRequestEntityTooLarge.Wrap(err)
+	}
+
+	if orbe, ok := err.(*orberrors.Error); ok { //nolint:errorlint
+		return orbe
+	}
+
+	return orberrors.HTTP(status).Wrap(err)
+}
+
+// statusError returns the error of a status code, 413 is ErrRequestEntityTooLarge.
+func statusError(status int) *orberrors.Error {
+	if status == consts.StatusRequestEntityTooLarge {
+		return ErrRequestEntityTooLarge
+	}
+
+	return orberrors.HTTP(status)
+}
@@ -0,0 +1,62 @@
+package hertz
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestDecodeErrorJSON(t *testing.T) {
+	err := decodeError(
+		400,
+		codecs.MimeJSON,
+		[]byte(`{"code":400,"message":"bad request","wrapped":{"message":"invalid name"}}`),
+	)
+
+	require.ErrorIs(t, err, orberrors.ErrBadRequest)
+	require.Equal(t, "bad request: invalid name", err.Error())
+}
+
+func TestDecodeErrorNested(t *testing.T) {
+	err := decodeError(
+		500,
+		codecs.MimeJSON,
+		[]byte(`{"message":"while calling: not found","wrapped":{"code":404,"message":"not found"}}`),
+	)
+
+	require.Equal(t, 500, err.Code)
+	require.ErrorIs(t, err, orberrors.ErrNotFound)
+}
+
+func TestDecodeErrorProto(t *testing.T) {
+	s, err := structpb.NewStruct(map[string]any{"code": 401, "message": "unauthorized"})
+	require.NoError(t, err)
+
+	data, err := proto.Marshal(s)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, decodeError(401, codecs.MimeProto, data), orberrors.ErrUnauthorized)
+}
+
+func TestDecodeErrorFallback(t *testing.T) {
+	err := decodeError(503, "text/plain", []byte("Service Unavailable"))
+
+	require.True(t, errors.Is(err, orberrors.ErrUnavailable))
+}
+
+func TestDecodeErrorTooLarge(t *testing.T) {
+	err := decodeError(
+		413,
+		codecs.MimeJSON,
+		[]byte(`{"code":413,"message":"Request Entity Too Large","wrapped":{"message":"request body too large"}}`),
+	)
+
+	require.ErrorIs(t, err, ErrRequestEntityTooLarge)
+	require.ErrorIs(t, decodeError(413, "text/plain", nil), ErrRequestEntityTooLarge)
+	require.NotErrorIs(t, decodeError(400, "text/plain", nil), ErrRequestEntityTooLarge)
+}
@@ -0,0 +1,337 @@
+package hertz
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-orb/go-orb/client"
+	"github.com/go-orb/go-orb/registry"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/proto"
+)
+
+// hedgesKey is the CallOptions.ResponseMetadata key with the number of hedged requests of a call.
+const hedgesKey = "x-hertz-hedges"
+
+// HedgePolicy configures hedged requests, see WithHedging.
+//
+// A hedged call sends the request to its node first. When there's no response
+// after the hedge delay, or the request failed with a 5xx error, it sends a copy
+// to another node of the service. The first successful response wins and
+// the other requests get canceled.
+//
+// Other errors stop hedging, but the requests in flight may still succeed,
+// the call waits for them. When all requests failed the call returns the
+// error of the last one.
+type HedgePolicy struct {
+	// Delay is the time to wait for a response before the next hedge. With
+	// a Percentile it's used until the endpoint has MinSamples latencies,
+	// 0 doesn't hedge until then.
+	Delay time.Duration
+
+	// Percentile of the observed latencies of the endpoint to wait before
+	// the next hedge, between 0 and 1, 0 always waits for the Delay.
+	Percentile float64
+
+	// Samples is the number of latencies kept per endpoint.
+	Samples int
+
+	// MinSamples is the number of latencies required for the Percentile.
+	MinSamples int
+
+	// MaxHedges is the maximum number of hedged requests per call.
+	MaxHedges int
+}
+
+// DefaultHedgePolicy returns a policy which sends a single hedge after the 95th
+// percentile of the last 200 latencies of the endpoint, until there are 20 of
+// them after 100 milliseconds.
+func DefaultHedgePolicy() HedgePolicy {
+	return HedgePolicy{
+		Delay:      100 * time.Millisecond,
+		Percentile: 0.95,
+		Samples:    200,
+		MinSamples: 20,
+		MaxHedges:  1,
+	}
+}
+
+// HedgeNodes returns the addresses of the nodes of the service
+// which can be reached with the named transport.
+type HedgeNodes func(ctx context.Context, service string, transport string) ([]string, error)
+
+// RegistryNodes returns HedgeNodes which looks up the nodes of the namespace
+// and region in the registry, the hertz entrypoint registers without them.
+func RegistryNodes(reg registry.Registry, namespace, region string) HedgeNodes {
+	return func(ctx context.Context, service string, transport string) ([]string, error) {
+		nodes, err := reg.GetService(ctx, namespace, region, service, []string{transport})
+		if err != nil {
+			return nil, err
+		}
+
+		addresses := []string{}
+
+		for _, node := range nodes {
+			if node.Scheme == transport {
+				addresses = append(addresses, node.Address)
+			}
+		}
+
+		return addresses, nil
+	}
+}
+
+// WithHedging hedges calls marked with WithIdempotent, the hedges get
+// sent to the nodes returned by nodes, see RegistryNodes.
+//
+// The number of hedged requests is reported in the "x-hertz-hedges"
+// key of the CallOptions.ResponseMetadata.
+func WithHedging(p HedgePolicy, nodes HedgeNodes) TransportOption {
+	return func(t *Transport) {
+		t.hedge = &hedging{
+			policy:    p,
+			nodes:     nodes,
+			latencies: make(map[string]*latencyWindow),
+		}
+	}
+}
+
+// hedging holds the policy and the observed latencies of the endpoints.
+type hedging struct {
+	policy HedgePolicy
+	nodes  HedgeNodes
+
+	mu        sync.Mutex
+	latencies map[string]*latencyWindow
+}
+
+// latencyWindow is a ring buffer with the last latencies of an endpoint.
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+}
+
+// observe records the latency of a successful request to the endpoint.
+func (h *hedging) observe(endpoint string, d time.Duration) {
+	size := max(h.policy.Samples, 1)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	w, ok := h.latencies[endpoint]
+	if !ok {
+		w = &latencyWindow{samples: make([]time.Duration, 0, size)}
+		h.latencies[endpoint] = w
+	}
+
+	if len(w.samples) < size {
+		w.samples = append(w.samples, d)
+		return
+	}
+
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % size
+}
+
+// delay returns how long to wait for a response before hedging
+// a request to the endpoint, false when it shouldn't be hedged.
+func (h *hedging) delay(endpoint string) (time.Duration, bool) {
+	if h.policy.Percentile <= 0 {
+		return h.policy.Delay, h.policy.Delay > 0
+	}
+
+	h.mu.Lock()
+
+	var samples []time.Duration
+	if w, ok := h.latencies[endpoint]; ok && len(w.samples) >= max(h.policy.MinSamples, 1) {
+		samples = slices.Clone(w.samples)
+	}
+
+	h.mu.Unlock()
+
+	if samples == nil {
+		return h.policy.Delay, h.policy.Delay > 0
+	}
+
+	slices.Sort(samples)
+
+	d := samples[max(int(math.Ceil(min(h.policy.Percentile, 1)*float64(len(samples))))-1, 0)]
+
+	return d, d > 0
+}
+
+// hedgeResult is the result of one of the requests of a hedged call.
+type hedgeResult struct {
+	err      error
+	result   any
+	metadata map[string]string
+}
+
+// hedgeable returns whether the failed request should be hedged right away.
+func hedgeable(err error) bool {
+	orbErr, ok := orberrors.As(err)
+	return ok && orbErr.Code >= 500
+}
+
+// hedgeRequest sends the request with hedges, each of them gets retried with the RetryPolicy.
+//
+//nolint:funlen
+func (t *Transport) hedgeRequest(
+	ctx context.Context,
+	infos client.RequestInfos,
+	req any,
+	result any,
+	opts *client.CallOptions,
+) error {
+	rv := reflect.ValueOf(result)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return t.retryRequest(ctx, infos, req, result, opts)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	endpoint := infos.Service + infos.Endpoint
+	results := make(chan hedgeResult, max(t.hedge.policy.MaxHedges, 0)+1)
+
+	send := func(address string) {
+		hInfos := infos
+		hInfos.Address = address
+
+		hOpts := *opts
+		if opts.ResponseMetadata != nil {
+			hOpts.ResponseMetadata = make(map[string]string)
+		}
+
+		hResult := reflect.New(rv.Type().Elem()).Interface()
+
+		go func() {
+			start := time.Now()
+
+			err := t.retryRequest(ctx, hInfos, req, hResult, &hOpts)
+			if err == nil {
+				t.hedge.observe(endpoint, time.Since(start))
+			}
+
+			results <- hedgeResult{err: err, result: hResult, metadata: hOpts.ResponseMetadata}
+		}()
+	}
+
+	used := []string{infos.Address}
+	hedges := 0
+	pending := 1
+	stopped := false
+
+	var candidates []string
+
+	hedge := func() bool {
+		if stopped || hedges >= t.hedge.policy.MaxHedges {
+			return false
+		}
+
+		if candidates == nil {
+			candidates = t.hedgeNodes(ctx, infos)
+		}
+
+		candidates = slices.DeleteFunc(candidates, func(address string) bool {
+			return slices.Contains(used, address) ||
+				(t.breakers != nil && t.breakers.State(address) == CircuitOpen)
+		})
+		if len(candidates) == 0 {
+			return false
+		}
+
+		address := candidates[rand.IntN(len(candidates))] //nolint:gosec
+		used = append(used, address)
+		hedges++
+		pending++
+
+		trace.SpanFromContext(ctx).AddEvent("hedge", trace.WithAttributes(
+			attribute.Int("hedge.count", hedges),
+			attrServerAddress.String(address),
+		))
+		t.logger.Debug("Hedging request", "endpoint", infos.Endpoint, "address", address, "hedge", hedges)
+
+		send(address)
+
+		return true
+	}
+
+	send(infos.Address)
+
+	var timer <-chan time.Time
+
+	if d, ok := t.hedge.delay(endpoint); ok {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+
+		timer = ticker.C
+	}
+
+	for {
+		select {
+		case <-timer:
+			hedge()
+		case res := <-results:
+			pending--
+
+			if res.err != nil {
+				// Failed requests get hedged right away, others stop hedging
+				// as the hedges would most likely fail the same way.
+				if !hedgeable(res.err) {
+					stopped = true
+				} else if hedge() {
+					continue
+				}
+
+				// Wait for the pending ones, they may still succeed.
+				if pending > 0 {
+					continue
+				}
+			} else {
+				setResult(result, res.result)
+			}
+
+			if opts.ResponseMetadata != nil {
+				for k, v := range res.metadata {
+					opts.ResponseMetadata[k] = v
+				}
+
+				opts.ResponseMetadata[hedgesKey] = strconv.Itoa(hedges)
+			}
+
+			return res.err
+		}
+	}
+}
+
+// hedgeNodes returns the addresses of the other nodes of the service.
+func (t *Transport) hedgeNodes(ctx context.Context, infos client.RequestInfos) []string {
+	addresses, err := t.hedge.nodes(ctx, infos.Service, t.name)
+	if err != nil {
+		t.logger.Debug("Failed to look up the nodes for hedging", "service", infos.Service, "error", err)
+		return []string{}
+	}
+
+	return slices.Clone(addresses)
+}
+
+// setResult copies the result of the winning request into the callers result.
+func setResult(dst any, src any) {
+	if dstMsg, ok := dst.(proto.Message); ok {
+		proto.Reset(dstMsg)
+		proto.Merge(dstMsg, src.(proto.Message))
+
+		return
+	}
+
+	reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(src).Elem())
+}
@@ -0,0 +1,193 @@
+package hertz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/client"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/log"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/go-orb/plugins/client/orb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-orb/plugins-experimental/server/hertz"
+)
+
+const hedgeEndpoint = "/test.Hedge/Get"
+
+// staticNodes returns HedgeNodes with the addresses.
+func staticNodes(addresses ...string) HedgeNodes {
+	return func(context.Context, string, string) ([]string, error) {
+		return addresses, nil
+	}
+}
+
+// slowRoute answers after the delay, with an error when the code is set.
+type slowRoute struct {
+	delay time.Duration
+	code  int
+}
+
+func (r *slowRoute) handle(_ context.Context, ctx *app.RequestContext) {
+	time.Sleep(r.delay)
+
+	if r.code != 0 {
+		hertz.WriteError(ctx, orberrors.HTTP(r.code))
+		return
+	}
+
+	ctx.Data(consts.StatusOK, codecs.MimeJSON, []byte(`{"text":"slow"}`))
+}
+
+// setupSlowRoute starts a server with the slow route on the hedgeEndpoint.
+func setupSlowRoute(t *testing.T, r *slowRoute) (string, log.Logger) {
+	t.Helper()
+
+	ep, logger := setupTestServer(t, func(s *hertz.Server) {
+		s.Router().POST(hedgeEndpoint, r.handle)
+	}, hertz.WithInsecure())
+
+	return ep.Address(), logger
+}
+
+func TestHedge(t *testing.T) {
+	slow := &slowRoute{delay: time.Second}
+	fast := &flakyRoute{}
+
+	slowAddress, logger := setupSlowRoute(t, slow)
+
+	fastAddress, _ := setupRetryServer(t, map[string]*flakyRoute{hedgeEndpoint: fast})
+
+	cfg := orb.NewConfig()
+
+	tt, err := NewHTTPTransport(logger, &cfg,
+		WithHedging(HedgePolicy{Delay: 20 * time.Millisecond, MaxHedges: 1}, staticNodes(slowAddress, fastAddress)))
+	require.NoError(t, err)
+
+	opts := &client.CallOptions{ContentType: codecs.MimeJSON, ResponseMetadata: map[string]string{}}
+	ctx := WithIdempotent(context.Background())
+
+	start := time.Now()
+	res := &streamMsg{}
+
+	err = tt.Request(
+		ctx,
+		client.RequestInfos{Service: "test.retry", Endpoint: hedgeEndpoint, Address: slowAddress},
+		&streamMsg{Text: "hello"},
+		res,
+		opts,
+	)
+	require.NoError(t, err)
+	require.Equal(t, "ok", res.Text)
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+	require.Equal(t, "1", opts.ResponseMetadata[hedgesKey])
+	require.Equal(t, int32(1), fast.calls.Load())
+
+	// Calls which aren't idempotent don't get hedged.
+	opts = &client.CallOptions{ContentType: codecs.MimeJSON, ResponseMetadata: map[string]string{}}
+
+	err = tt.Request(
+		context.Background(),
+		client.RequestInfos{Service: "test.retry", Endpoint: hedgeEndpoint, Address: slowAddress},
+		&streamMsg{Text: "hello"},
+		&streamMsg{},
+		opts,
+	)
+	require.NoError(t, err)
+	require.NotContains(t, opts.ResponseMetadata, hedgesKey)
+	require.Equal(t, int32(1), fast.calls.Load())
+}
+
+func TestHedgeFailure(t *testing.T) {
+	failing := &flakyRoute{failures: 10, code: consts.StatusServiceUnavailable}
+	healthy := &flakyRoute{}
+
+	failingAddress, logger := setupRetryServer(t, map[string]*flakyRoute{hedgeEndpoint: failing})
+	healthyAddress, _ := setupRetryServer(t, map[string]*flakyRoute{hedgeEndpoint: healthy})
+
+	cfg := orb.NewConfig()
+
+	// The failed request gets hedged right away, without waiting for the delay.
+	tt, err := NewHTTPTransport(logger, &cfg,
+		WithHedging(HedgePolicy{Delay: time.Minute, MaxHedges: 1}, staticNodes(failingAddress, healthyAddress)))
+	require.NoError(t, err)
+
+	opts := &client.CallOptions{ContentType: codecs.MimeJSON, ResponseMetadata: map[string]string{}}
+	ctx := WithIdempotent(context.Background())
+
+	err = tt.Request(
+		ctx,
+		client.RequestInfos{Service: "test.retry", Endpoint: hedgeEndpoint, Address: failingAddress},
+		&streamMsg{Text: "hello"},
+		&streamMsg{},
+		opts,
+	)
+	require.NoError(t, err)
+	require.Equal(t, "1", opts.ResponseMetadata[hedgesKey])
+	require.Equal(t, int32(1), failing.calls.Load())
+	require.Equal(t, int32(1), healthy.calls.Load())
+}
+
+func TestHedgeClientError(t *testing.T) {
+	// The first request fails with a client error while the hedge is in flight.
+	failing := &slowRoute{delay: 200 * time.Millisecond, code: consts.StatusBadRequest}
+	slow := &slowRoute{delay: 400 * time.Millisecond}
+
+	failingAddress, logger := setupSlowRoute(t, failing)
+	slowAddress, _ := setupSlowRoute(t, slow)
+
+	cfg := orb.NewConfig()
+
+	tt, err := NewHTTPTransport(logger, &cfg,
+		WithHedging(HedgePolicy{Delay: 50 * time.Millisecond, MaxHedges: 1}, staticNodes(failingAddress, slowAddress)))
+	require.NoError(t, err)
+
+	opts := &client.CallOptions{ContentType: codecs.MimeJSON, ResponseMetadata: map[string]string{}}
+	res := &streamMsg{}
+
+	// The call waits for the hedge, it succeeds.
+	err = tt.Request(
+		WithIdempotent(context.Background()),
+		client.RequestInfos{Service: "test.retry", Endpoint: hedgeEndpoint, Address: failingAddress},
+		&streamMsg{Text: "hello"},
+		res,
+		opts,
+	)
+	require.NoError(t, err)
+	require.Equal(t, "slow", res.Text)
+	require.Equal(t, "1", opts.ResponseMetadata[hedgesKey])
+}
+
+func TestHedgeDelay(t *testing.T) {
+	h := &hedging{
+		policy:    HedgePolicy{Delay: time.Second, Percentile: 0.9, Samples: 10, MinSamples: 5},
+		latencies: make(map[string]*latencyWindow),
+	}
+
+	for i := range 4 {
+		h.observe("/test", time.Duration(i+1)*time.Millisecond)
+	}
+
+	// The Delay is used until there are MinSamples.
+	d, ok := h.delay("/test")
+	require.True(t, ok)
+	require.Equal(t, time.Second, d)
+
+	for i := 4; i < 20; i++ {
+		h.observe("/test", time.Duration(i+1)*time.Millisecond)
+	}
+
+	// Only the last 10 latencies, 11-20ms, are kept.
+	d, ok = h.delay("/test")
+	require.True(t, ok)
+	require.Equal(t, 19*time.Millisecond, d)
+
+	h.policy = HedgePolicy{}
+
+	_, ok = h.delay("/test")
+	require.False(t, ok)
+}
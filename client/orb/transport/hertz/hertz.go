@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"sync"
 
 	hclient "github.com/cloudwego/hertz/pkg/app/client"
 	"github.com/cloudwego/hertz/pkg/protocol"
@@ -23,36 +24,121 @@ import (
 )
 
 func init() {
-	orb.RegisterTransport("hertzh2c", NewH2CTransport)
-	orb.RegisterTransport("hertzhttp", NewHTTPTransport)
+	orb.RegisterTransport("hertzh2c", NewFactory(NewH2CTransport))
+	orb.RegisterTransport("hertzhttp", NewFactory(NewHTTPTransport))
+	orb.RegisterTransport("hertzhttps", NewFactory(NewHTTPSTransport))
+	orb.RegisterTransport("hertzh2", NewFactory(NewH2Transport))
 }
 
 //nolint:gochecknoglobals
-var stdHeaders = []string{"Content-Length", "Content-Type", "Date", "Server"}
+var stdHeaders = []string{"Content-Encoding", "Content-Length", "Content-Type", "Date", "Server", "Vary"}
 
 var _ (orb.Transport) = (*Transport)(nil)
 
 // TransportClientCreator is a factory for a client transport.
 type TransportClientCreator func() (*hclient.Client, error)
 
+// TransportOption configures a transport.
+type TransportOption func(*Transport)
+
+// NewFactory returns a factory for orb.RegisterTransport which creates
+// transports with the options, to replace the registered transports:
+//
+//	orb.RegisterTransport("hertzhttp", hertz.NewFactory(hertz.NewHTTPTransport,
+//		hertz.WithRetryPolicy(hertz.DefaultRetryPolicy())))
+func NewFactory(
+	newTransport func(log.Logger, *orb.Config, ...TransportOption) (orb.TransportType, error),
+	opts ...TransportOption,
+) orb.TransportFactory {
+	return func(logger log.Logger, cfg *orb.Config) (orb.TransportType, error) {
+		return newTransport(logger, cfg, opts...)
+	}
+}
+
 // Transport is a go-orb/plugins/client/orb compatible transport.
 type Transport struct {
-	name          string
-	logger        log.Logger
-	clientCreator TransportClientCreator
-	hclient       *hclient.Client
-	scheme        string
+	name      string
+	logger    log.Logger
+	newClient func(*Transport) (*hclient.Client, error)
+	scheme    string
+
+	// mu guards hclient, it gets created by Start or the first request.
+	mu      sync.Mutex
+	hclient *hclient.Client
+
+	// http2 is true when the transport talks HTTP/2, which is required for streaming.
+	http2 bool
+
+	// pool is the connection pool configuration, conns are the connections
+	// dialed by the built-in transports.
+	pool  poolConfig
+	conns *connSet
+
+	// drain counts the requests in flight for Stop.
+	drain *drainer
+
+	// retry is the retry policy of WithRetryPolicy, nil disables retries.
+	retry *RetryPolicy
+
+	// breakers are the circuit breakers of WithCircuitBreakers, nil disables them.
+	breakers *CircuitBreakers
+
+	// hedge is the hedging of WithHedging, nil disables it.
+	hedge *hedging
+
+	// tls are the TLS settings of the HTTPS transports.
+	tls tlsOptions
+
+	// tracing are the settings of WithTracerProvider and WithPropagator.
+	tracing tracingOptions
 }
 
-// Start starts the transport.
+// Start creates the hertz client, after Stop it creates a new one.
 func (t *Transport) Start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.hclient != nil {
+		return nil
+	}
+
+	t.drain.reset()
+
+	hclient, err := t.newClient(t)
+	if err != nil {
+		return err
+	}
+
+	t.hclient = hclient
+
 	return nil
 }
 
-// Stop stop the transport.
-func (t *Transport) Stop(_ context.Context) error {
-	if t.hclient != nil {
-		t.hclient.CloseIdleConnections()
+// Stop stops the transport, new requests fail with ErrTransportStopped until
+// the next Start.
+//
+// It waits for the requests and streams in flight until ctx is done, then
+// closes all connections. When requests had to be aborted it returns
+// an error which wraps ErrStopTimeout.
+func (t *Transport) Stop(ctx context.Context) error {
+	aborted := t.drain.wait(ctx)
+
+	t.mu.Lock()
+	hclient := t.hclient
+	t.hclient = nil
+	t.mu.Unlock()
+
+	if hclient != nil {
+		hclient.CloseIdleConnections()
+	}
+
+	// Close the connections of aborted requests and those hertz keeps
+	// outside of its idle pool, like the HTTP/2 connections.
+	t.conns.closeAll()
+
+	if aborted > 0 {
+		t.logger.Warn("Aborted requests while stopping", "transport", t.name, "aborted", aborted)
+		return fmt.Errorf("%w: aborted %d requests", ErrStopTimeout, aborted)
 	}
 
 	return nil
@@ -64,12 +150,98 @@ func (t *Transport) Name() string {
 }
 
 // Request does the actual rpc request to the server.
+//
+// It creates a client span with the provider of WithTracerProvider and sends its
+// context in the headers of WithPropagator, W3C traceparent and tracestate by default.
+//
+// With a RetryPolicy failed attempts get retried, all attempts share the
+// deadline of the context and opts.RequestTimeout. With CircuitBreakers
+// requests to a node with an open breaker fail fast with ErrCircuitOpen.
+// Calls marked with WithIdempotent get hedged with WithHedging.
 func (t *Transport) Request(
 	ctx context.Context,
 	infos client.RequestInfos,
 	req any,
 	result any,
 	opts *client.CallOptions,
+) (err error) {
+	ctx, span := t.startSpan(ctx, infos)
+	defer func() { endSpan(span, err) }()
+
+	gen := t.drain.begin()
+	if gen == nil {
+		return orberrors.ErrUnavailable.Wrap(ErrTransportStopped)
+	}
+	defer gen.end()
+
+	if t.hedge != nil && isIdempotent(ctx) {
+		return t.hedgeRequest(ctx, infos, req, result, opts)
+	}
+
+	return t.retryRequest(ctx, infos, req, result, opts)
+}
+
+// retryRequest sends the request and retries it with the RetryPolicy.
+func (t *Transport) retryRequest(
+	ctx context.Context,
+	infos client.RequestInfos,
+	req any,
+	result any,
+	opts *client.CallOptions,
+) error {
+	if t.retry == nil || t.retry.MaxAttempts <= 1 {
+		return t.attempt(ctx, infos, req, result, opts, &attemptResult{})
+	}
+
+	if opts.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, opts.RequestTimeout)
+		defer cancel()
+	}
+
+	idempotent := isIdempotent(ctx)
+
+	for attempt := 1; ; attempt++ {
+		res := &attemptResult{}
+
+		err := t.attempt(ctx, infos, req, result, opts, res)
+		if err == nil || ctx.Err() != nil || !t.retry.retryable(attempt, idempotent, res) {
+			return err
+		}
+
+		delay, ok := t.retry.backoff(attempt, res.retryAfter)
+		if !ok || !waitRetry(ctx, attempt, delay) {
+			return err
+		}
+
+		t.logger.Debug("Retrying request", "endpoint", infos.Endpoint, "address", infos.Address,
+			"attempt", attempt+1, "error", err)
+	}
+}
+
+// attempt sends a single request through the circuit breaker of the node.
+func (t *Transport) attempt(
+	ctx context.Context,
+	infos client.RequestInfos,
+	req any,
+	result any,
+	opts *client.CallOptions,
+	res *attemptResult,
+) error {
+	return t.guard(ctx, infos, res, func() error {
+		return t.request(ctx, infos, req, result, opts, res)
+	})
+}
+
+// request sends a single request, res describes how it failed.
+func (t *Transport) request(
+	ctx context.Context,
+	infos client.RequestInfos,
+	req any,
+	result any,
+	opts *client.CallOptions,
+	res *attemptResult,
 ) error {
 	codec, err := codecs.GetEncoder(opts.ContentType, req)
 	if err != nil {
@@ -85,10 +257,9 @@ func (t *Transport) Request(
 	// Create a hertz request.
 	hReq := &protocol.Request{}
 	hReq.SetMethod(consts.MethodPost)
-	hReq.SetBodyStream(buff, buff.Len())
 	hReq.Header.SetContentTypeBytes([]byte(opts.ContentType))
 	hReq.Header.Set("Accept", opts.ContentType)
-	hReq.SetRequestURI(fmt.Sprintf("%s://%s%s", t.scheme, infos.Address, infos.Endpoint))
+	hReq.SetRequestURI(fmt.Sprintf("%s://%s%s", t.scheme, requestHost(infos.Address), infos.Endpoint))
 
 	// Set metadata key=value to request headers.
 	md, ok := metadata.Outgoing(ctx)
@@ -98,26 +269,41 @@ func (t *Transport) Request(
 		}
 	}
 
-	// Get the client
-	if t.hclient == nil {
-		hclient, err := t.clientCreator()
-		if err != nil {
-			return err
-		}
+	t.injectSpan(ctx, hReq)
 
-		t.hclient = hclient
+	if err := compressRequest(ctx, hReq, buff.Bytes()); err != nil {
+		return err
+	}
+
+	// Tell the server how long we wait for the response.
+	timeout, err := requestTimeout(ctx, opts.RequestTimeout)
+	if err != nil {
+		return err
 	}
 
+	setTimeout(hReq, timeout)
+
+	// Get the client
+	hclient, err := t.client()
+	if err != nil {
+		return err
+	}
+
+	done := t.limitIdle(hReq, infos.Address)
+	defer done()
+
 	// Run the request.
 	hRes := &protocol.Response{}
 
-	err = t.hclient.DoTimeout(ctx, hReq, hRes, opts.RequestTimeout)
+	err = hclient.DoTimeout(ctx, hReq, hRes, timeout)
 	if err != nil {
+		res.dialFailed = isDialError(err)
+		res.connFailed = !res.dialFailed
+
 		return orberrors.From(err)
 	}
 
-	// Read into a bytes.Buffer.
-	buff = bytes.NewBuffer(hRes.Body())
+	traceResponse(ctx, hReq, hRes)
 
 	if opts.ResponseMetadata != nil {
 		for _, v := range hRes.Header.GetHeaders() {
@@ -132,12 +318,20 @@ func (t *Transport) Request(
 		}
 	}
 
+	body, err := responseBody(hRes, opts)
+	if err != nil {
+		return err
+	}
+
 	if hRes.StatusCode() != consts.StatusOK {
-		return orberrors.HTTP(hRes.StatusCode())
+		res.status = hRes.StatusCode()
+		res.retryAfter = parseRetryAfter(hRes.Header.Get(retryAfterHeader))
+
+		return decodeError(hRes.StatusCode(), string(hRes.Header.ContentType()), body)
 	}
 
 	// Decode the response into `result`.
-	err = codec.NewDecoder(buff).Decode(result)
+	err = codec.NewDecoder(bytes.NewReader(body)).Decode(result)
 	if err != nil {
 		return orberrors.ErrBadRequest.Wrap(err)
 	}
@@ -145,56 +339,139 @@ func (t *Transport) Request(
 	return nil
 }
 
-// Stream creates a bidirectional stream to the service endpoint.
-// Hertz transport does not support streaming operations by default.
-func (t *Transport) Stream(_ context.Context, _ client.RequestInfos, _ *client.CallOptions) (client.StreamIface[any, any], error) {
-	return nil, orberrors.HTTP(501).Wrap(client.ErrStreamNotSupported)
+// client returns the hertz client, it creates it when Start hasn't been called.
+func (t *Transport) client() (*hclient.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.hclient == nil {
+		hclient, err := t.newClient(t)
+		if err != nil {
+			return nil, err
+		}
+
+		t.hclient = hclient
+	}
+
+	return t.hclient, nil
 }
 
 // NewTransport creates a Transport with a custom http.Client.
+// Transports created with it don't support streaming, the
+// connection pool options don't apply to its client.
 func NewTransport(name string, logger log.Logger, scheme string, clientCreator TransportClientCreator,
+	opts ...TransportOption,
 ) (orb.TransportType, error) {
-	return orb.TransportType{Transport: &Transport{
-		name:          name,
-		logger:        logger,
-		scheme:        scheme,
-		clientCreator: clientCreator,
-	}}, nil
+	return newTransport(name, logger, scheme, false, func(*Transport) (*hclient.Client, error) {
+		return clientCreator()
+	}, opts...)
+}
+
+func newTransport(name string, logger log.Logger, scheme string, http2 bool,
+	newClient func(*Transport) (*hclient.Client, error),
+	opts ...TransportOption,
+) (orb.TransportType, error) {
+	t := &Transport{
+		name:      name,
+		logger:    logger,
+		scheme:    scheme,
+		http2:     http2,
+		newClient: newClient,
+		conns:     newConnSet(),
+		drain:     newDrainer(),
+	}
+
+	for _, o := range opts {
+		o(t)
+	}
+
+	return orb.TransportType{Transport: t}, nil
 }
 
 // NewH2CTransport creates a new hertz http transport for the orb client.
-func NewH2CTransport(logger log.Logger, cfg *orb.Config) (orb.TransportType, error) {
-	return NewTransport(
+func NewH2CTransport(logger log.Logger, cfg *orb.Config, opts ...TransportOption) (orb.TransportType, error) {
+	return newTransport(
 		"hertzh2c",
 		logger,
 		"http",
-		func() (*hclient.Client, error) {
-			c, err := hclient.NewClient(
-				hclient.WithNoDefaultUserAgentHeader(true),
-				hclient.WithMaxConnsPerHost(cfg.PoolSize),
-			)
+		true,
+		func(t *Transport) (*hclient.Client, error) {
+			c, err := hclient.NewClient(t.clientOptions(cfg.PoolSize)...)
 			if err != nil {
 				return nil, err
 			}
 
-			c.SetClientFactory(factory.NewClientFactory(config.WithAllowHTTP(true)))
+			c.SetClientFactory(factory.NewClientFactory(
+				append(t.http2Options(), config.WithAllowHTTP(true))...,
+			))
 
 			return c, nil
 		},
+		opts...,
 	)
 }
 
 // NewHTTPTransport creates a new hertz http transport for the orb client.
-func NewHTTPTransport(logger log.Logger, cfg *orb.Config) (orb.TransportType, error) {
-	return NewTransport(
+func NewHTTPTransport(logger log.Logger, cfg *orb.Config, opts ...TransportOption) (orb.TransportType, error) {
+	return newTransport(
 		"hertzhttp",
 		logger,
 		"http",
-		func() (*hclient.Client, error) {
+		false,
+		func(t *Transport) (*hclient.Client, error) {
+			return hclient.NewClient(
+				append(t.clientOptions(cfg.PoolSize),
+					hclient.WithDialer(newUnixDialer(t.dialer())),
+				)...,
+			)
+		},
+		opts...,
+	)
+}
+
+// NewHTTPSTransport creates a new hertz https transport for the orb client.
+//
+// It uses the TLS config of the orb client, WithRootCAs, WithClientCertificates,
+// WithServerName and WithInsecureSkipVerify override its settings.
+func NewHTTPSTransport(logger log.Logger, cfg *orb.Config, opts ...TransportOption) (orb.TransportType, error) {
+	return newTransport(
+		"hertzhttps",
+		logger,
+		"https",
+		false,
+		func(t *Transport) (*hclient.Client, error) {
 			return hclient.NewClient(
-				hclient.WithNoDefaultUserAgentHeader(true),
-				hclient.WithMaxConnsPerHost(cfg.PoolSize),
+				append(t.clientOptions(cfg.PoolSize),
+					hclient.WithTLSConfig(t.tlsConfig(cfg, "http/1.1")),
+					hclient.WithDialer(newUnixDialer(t.dialer())),
+				)...,
 			)
 		},
+		opts...,
+	)
+}
+
+// NewH2Transport creates a new hertz HTTP/2 over TLS transport for the orb client.
+//
+// See NewHTTPSTransport for the TLS configuration.
+func NewH2Transport(logger log.Logger, cfg *orb.Config, opts ...TransportOption) (orb.TransportType, error) {
+	return newTransport(
+		"hertzh2",
+		logger,
+		"https",
+		true,
+		func(t *Transport) (*hclient.Client, error) {
+			c, err := hclient.NewClient(t.clientOptions(cfg.PoolSize)...)
+			if err != nil {
+				return nil, err
+			}
+
+			c.SetClientFactory(factory.NewClientFactory(
+				append(t.http2Options(), config.WithTLSConfig(t.tlsConfig(cfg, "h2")))...,
+			))
+
+			return c, nil
+		},
+		opts...,
 	)
 }
@@ -9,7 +9,9 @@ import (
 	"github.com/go-orb/go-orb/registry"
 	"github.com/go-orb/go-orb/server"
 	"github.com/go-orb/go-orb/types"
+	"github.com/go-orb/plugins/client/orb"
 	"github.com/go-orb/plugins/client/tests"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/go-orb/plugins-experimental/server/hertz"
@@ -82,9 +84,26 @@ func setupServer(sn string) (*tests.SetupData, error) {
 		return nil, err
 	}
 
+	// HTTP/2 over TLS with a self-signed certificate.
+	ep3, err := hertz.New(
+		sn, sv,
+		"hertzh2",
+		hertz.NewConfig(
+			hertz.WithHandlers(hRegister),
+			hertz.WithClientTransport("hertzh2"),
+		),
+		logger,
+		reg,
+	)
+	if err != nil {
+		cancel()
+
+		return nil, err
+	}
+
 	setupData.Logger = logger
 	setupData.Registry = reg
-	setupData.Entrypoints = []server.Entrypoint{ep1, ep2}
+	setupData.Entrypoints = []server.Entrypoint{ep1, ep2, ep3}
 	setupData.Ctx = ctx
 	setupData.Stop = cancel
 
@@ -92,7 +111,11 @@ func setupServer(sn string) (*tests.SetupData, error) {
 }
 
 func newSuite() *tests.TestSuite {
-	s := tests.NewSuite(setupServer, []string{"hertzhttp", "hertzh2c"})
+	// The suite creates its transports from the registered factories,
+	// they have to accept the self-signed certificate of the hertzh2 entrypoint.
+	orb.Transports.Set("hertzh2", NewFactory(NewH2Transport, WithInsecureSkipVerify()))
+
+	s := tests.NewSuite(setupServer, []string{"hertzhttp", "hertzh2c", "hertzh2"})
 	// s.Debug = true
 	return s
 }
@@ -105,3 +128,42 @@ func TestSuite(t *testing.T) {
 	// Run the tests.
 	suite.Run(t, newSuite())
 }
+
+// setupTestServer starts a hertz entrypoint with the options, register adds
+// the routes of the test. The entrypoint gets stopped once the test is done,
+// the logger is for the transports.
+func setupTestServer(
+	t *testing.T,
+	register func(s *hertz.Server),
+	opts ...server.Option,
+) (server.Entrypoint, log.Logger) {
+	t.Helper()
+
+	if os.Getenv("CI") != "" {
+		t.Skip("Skipping testing in CI environment")
+	}
+
+	logger, err := log.New()
+	require.NoError(t, err)
+
+	reg, err := registry.New(nil, &types.Components{}, logger)
+	require.NoError(t, err)
+
+	opts = append(opts, hertz.WithHandlers(func(srv any) {
+		s, ok := srv.(*hertz.Server)
+		if ok && register != nil {
+			register(s)
+		}
+	}))
+
+	ep, err := hertz.New("test.hertz", "v1.0.0", "hertztest", hertz.NewConfig(opts...), logger, reg)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, ep.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, ep.Stop(ctx))
+	})
+
+	return ep, logger
+}
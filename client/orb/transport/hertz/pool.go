@@ -0,0 +1,315 @@
+package hertz
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	hclient "github.com/cloudwego/hertz/pkg/app/client"
+	hconfig "github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/network"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/hertz-contrib/http2/config"
+)
+
+// ErrConnNotOwned is returned by the dialer for TLS upgrades of connections it didn't dial.
+var ErrConnNotOwned = errors.New("connection not dialed by the hertz transport")
+
+// poolConfig is the connection pool configuration of the transport, see the TransportOptions below.
+type poolConfig struct {
+	idleTimeout     time.Duration
+	maxIdlePerHost  int
+	dialTimeout     time.Duration
+	keepAlive       time.Duration
+	maxConnLifetime time.Duration
+	readBufferSize  int
+	writeBufferSize int
+}
+
+// WithIdleTimeout closes connections which have been idle for d, hertz defaults to 10 seconds.
+func WithIdleTimeout(d time.Duration) TransportOption {
+	return func(t *Transport) {
+		t.pool.idleTimeout = d
+	}
+}
+
+// WithMaxIdlePerHost limits the idle HTTP/1 connections kept per host, connections
+// above get closed after their response. The default 0 keeps all of them, up
+// to the PoolSize of the orb client. HTTP/2 multiplexes a single connection.
+func WithMaxIdlePerHost(n int) TransportOption {
+	return func(t *Transport) {
+		t.pool.maxIdlePerHost = n
+	}
+}
+
+// WithDialTimeout limits the time to establish a connection, including the TLS handshake.
+func WithDialTimeout(d time.Duration) TransportOption {
+	return func(t *Transport) {
+		t.pool.dialTimeout = d
+	}
+}
+
+// WithKeepAlive sets the interval of the TCP keep-alive probes,
+// a negative interval disables them, 0 uses the system default.
+func WithKeepAlive(d time.Duration) TransportOption {
+	return func(t *Transport) {
+		t.pool.keepAlive = d
+	}
+}
+
+// WithMaxConnLifetime closes HTTP/1 connections after their response once they're older than d.
+func WithMaxConnLifetime(d time.Duration) TransportOption {
+	return func(t *Transport) {
+		t.pool.maxConnLifetime = d
+	}
+}
+
+// WithBufferSizes sets the sizes of the read and write buffers of each connection,
+// they default to 4 KiB. Larger buffers need fewer syscalls for large messages.
+func WithBufferSizes(read, write int) TransportOption {
+	return func(t *Transport) {
+		t.pool.readBufferSize = read
+		t.pool.writeBufferSize = write
+	}
+}
+
+// clientOptions returns the hertz client options of the pool configuration.
+func (t *Transport) clientOptions(poolSize int) []hconfig.ClientOption {
+	opts := []hconfig.ClientOption{
+		hclient.WithNoDefaultUserAgentHeader(true),
+		hclient.WithMaxConnsPerHost(poolSize),
+	}
+
+	if t.pool.idleTimeout > 0 {
+		opts = append(opts, hclient.WithMaxIdleConnDuration(t.pool.idleTimeout))
+	}
+
+	if t.pool.dialTimeout > 0 {
+		opts = append(opts, hclient.WithDialTimeout(t.pool.dialTimeout))
+	}
+
+	if t.pool.maxConnLifetime > 0 {
+		opts = append(opts, hclient.WithMaxConnDuration(t.pool.maxConnLifetime))
+	}
+
+	return opts
+}
+
+// http2Options returns the options of the HTTP/2 client factory.
+func (t *Transport) http2Options() []config.ClientOption {
+	opts := []config.ClientOption{
+		config.WithDialer(newUnixDialer(t.dialer())),
+	}
+
+	if t.pool.idleTimeout > 0 {
+		opts = append(opts, config.WithMaxIdleConnDuration(t.pool.idleTimeout))
+	}
+
+	if t.pool.dialTimeout > 0 {
+		opts = append(opts, config.WithDialTimeout(t.pool.dialTimeout))
+	}
+
+	return opts
+}
+
+// limitIdle closes the connection of the HTTP/1 request after its response when it
+// would exceed WithMaxIdlePerHost, the returned func must be called once it's done.
+func (t *Transport) limitIdle(hReq *protocol.Request, address string) func() {
+	if t.http2 || t.pool.maxIdlePerHost <= 0 {
+		return func() {}
+	}
+
+	address = poolAddress(address, t.scheme == "https")
+
+	if t.conns.begin(address, t.pool.maxIdlePerHost) {
+		hReq.SetConnectionClose()
+	}
+
+	return func() { t.conns.end(address) }
+}
+
+// dialer returns the dialer of the transport, it tracks the connections for Stop.
+func (t *Transport) dialer() network.Dialer {
+	return &poolDialer{pool: &t.pool, conns: t.conns}
+}
+
+// poolAddress returns the address under which the connections to address get tracked.
+func poolAddress(address string, isTLS bool) string {
+	if path, ok := isUnixAddress(address); ok {
+		return path
+	}
+
+	return utils.AddMissingPort(address, isTLS)
+}
+
+// connSet tracks the open connections of a transport per address.
+type connSet struct {
+	mu       sync.Mutex
+	conns    map[*conn]string
+	open     map[string]int
+	inFlight map[string]int
+}
+
+func newConnSet() *connSet {
+	return &connSet{
+		conns:    make(map[*conn]string),
+		open:     make(map[string]int),
+		inFlight: make(map[string]int),
+	}
+}
+
+func (s *connSet) add(c *conn, address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.conns[c] = address
+	s.open[address]++
+}
+
+// remove stops tracking the connection, it returns its address.
+func (s *connSet) remove(c *conn) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	address, ok := s.conns[c]
+	if !ok {
+		return ""
+	}
+
+	delete(s.conns, c)
+
+	if s.open[address]--; s.open[address] <= 0 {
+		delete(s.open, address)
+	}
+
+	return address
+}
+
+// begin counts a request to the address, it returns whether its connection
+// should be closed after the response to keep at most maxIdle idle connections.
+func (s *connSet) begin(address string, maxIdle int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inFlight[address]++
+	n := s.inFlight[address]
+
+	// Concurrent requests above maxIdle leave a connection too many once they're
+	// done, as do all requests while the other connections would be idle.
+	return n > maxIdle || s.open[address]-(n-1) > maxIdle
+}
+
+// end removes a request added by begin.
+func (s *connSet) end(address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.inFlight[address]--; s.inFlight[address] <= 0 {
+		delete(s.inFlight, address)
+	}
+}
+
+// closeAll closes all connections, it returns how many were open.
+func (s *connSet) closeAll() int {
+	s.mu.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		_ = c.Close() //nolint:errcheck
+	}
+
+	return len(conns)
+}
+
+// poolDialer dials the connections of the transports with the pool configuration.
+type poolDialer struct {
+	pool  *poolConfig
+	conns *connSet
+}
+
+func (d *poolDialer) netDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{Timeout: timeout, KeepAlive: d.pool.keepAlive}
+}
+
+// DialConnection dials the address, TLS connections finish their handshake within the timeout.
+func (d *poolDialer) DialConnection(
+	nw, address string,
+	timeout time.Duration,
+	tlsConfig *tls.Config,
+) (network.Conn, error) {
+	ctx := context.Background()
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	c, err := d.netDialer(timeout).DialContext(ctx, nw, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig != nil {
+		tc := tls.Client(c, tlsConfig)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			_ = c.Close() //nolint:errcheck
+			return nil, err
+		}
+
+		c = tc
+	}
+
+	if nw != "unix" {
+		address = poolAddress(address, tlsConfig != nil)
+	}
+
+	return d.wrap(c, address), nil
+}
+
+// DialTimeout dials a plain net.Conn, like hertz' standard dialer it ignores the tlsConfig.
+func (d *poolDialer) DialTimeout(
+	nw, address string,
+	timeout time.Duration,
+	_ *tls.Config,
+) (net.Conn, error) {
+	return d.netDialer(timeout).Dial(nw, address)
+}
+
+func (d *poolDialer) AddTLS(nc network.Conn, tlsConfig *tls.Config) (network.Conn, error) {
+	pc, ok := nc.(*conn)
+	if !ok {
+		return nil, ErrConnNotOwned
+	}
+
+	tc := tls.Client(pc.NetConn(), tlsConfig)
+	if err := tc.Handshake(); err != nil {
+		return nil, err
+	}
+
+	return d.wrap(tc, d.conns.remove(pc)), nil
+}
+
+// wrap tracks the connection and wraps it into a network.Conn.
+func (d *poolDialer) wrap(c net.Conn, address string) network.Conn {
+	pc := newConn(c, d.pool.readBufferSize, d.pool.writeBufferSize, nil)
+	pc.onClose = func() { d.conns.remove(pc) }
+
+	d.conns.add(pc, address)
+
+	if tc, ok := c.(*tls.Conn); ok {
+		return &tlsConn{conn: pc, tc: tc}
+	}
+
+	return pc
+}
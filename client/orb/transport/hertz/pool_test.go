@@ -0,0 +1,270 @@
+package hertz
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+
+	hclient "github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/go-orb/go-orb/client"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/log"
+	"github.com/go-orb/go-orb/server"
+	"github.com/go-orb/plugins/client/orb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-orb/plugins-experimental/server/hertz"
+)
+
+const slowEndpoint = "/test.Pool/Slow"
+
+// slowHandler blocks until release gets closed.
+type slowHandler struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (h *slowHandler) handle(_ context.Context, req *streamMsg) (*streamMsg, error) {
+	h.entered <- struct{}{}
+	<-h.release
+
+	return req, nil
+}
+
+// setupSlowServer starts an entrypoint with the slow endpoint of h.
+func setupSlowServer(t *testing.T, h *slowHandler) (server.Entrypoint, log.Logger) {
+	t.Helper()
+
+	return setupTestServer(t, func(s *hertz.Server) {
+		s.Router().POST(slowEndpoint, hertz.NewGRPCHandler(s, h.handle, "test.Pool", "Slow"))
+	}, hertz.WithInsecure())
+}
+
+// poolRequest calls the slow endpoint with the transport in the background.
+func poolRequest(tt orb.TransportType, address string) <-chan error {
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- tt.Request(
+			context.Background(),
+			client.RequestInfos{Service: "test.hertz", Endpoint: slowEndpoint, Address: address},
+			&streamMsg{Text: "slow"},
+			&streamMsg{},
+			&client.CallOptions{ContentType: codecs.MimeJSON},
+		)
+	}()
+
+	return errCh
+}
+
+func TestTransportStop(t *testing.T) {
+	h := &slowHandler{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	ep, logger := setupSlowServer(t, h)
+
+	cfg := orb.NewConfig()
+
+	tt, err := NewHTTPTransport(logger, &cfg)
+	require.NoError(t, err)
+	require.NoError(t, tt.Start())
+
+	reqErr := poolRequest(tt, ep.Address())
+	<-h.entered
+
+	// Starting the running transport again keeps tracking the request.
+	require.NoError(t, tt.Start())
+
+	stopErr := make(chan error, 1)
+
+	go func() {
+		stopErr <- tt.Stop(context.Background())
+	}()
+
+	tr, ok := tt.Transport.(*Transport)
+	require.True(t, ok)
+
+	require.Eventually(t, func() bool {
+		gen := tr.drain.current()
+
+		gen.mu.Lock()
+		defer gen.mu.Unlock()
+
+		return gen.draining
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// New requests get rejected while the slow request is in flight.
+	require.ErrorIs(t, <-poolRequest(tt, ep.Address()), ErrTransportStopped)
+
+	select {
+	case err := <-stopErr:
+		t.Fatalf("Stop returned before the request finished: %v", err)
+	default:
+	}
+
+	close(h.release)
+
+	require.NoError(t, <-reqErr)
+	require.NoError(t, <-stopErr)
+	require.Empty(t, tr.conns.conns)
+
+	err = <-poolRequest(tt, ep.Address())
+	require.ErrorIs(t, err, ErrTransportStopped)
+
+	// The transport works again after a restart.
+	require.NoError(t, tt.Start())
+
+	reqErr = poolRequest(tt, ep.Address())
+	<-h.entered
+	require.NoError(t, <-reqErr)
+}
+
+func TestTransportStopTimeout(t *testing.T) {
+	h := &slowHandler{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	ep, logger := setupSlowServer(t, h)
+
+	t.Cleanup(func() { close(h.release) })
+
+	cfg := orb.NewConfig()
+
+	tt, err := NewHTTPTransport(logger, &cfg)
+	require.NoError(t, err)
+	require.NoError(t, tt.Start())
+
+	reqErr := poolRequest(tt, ep.Address())
+	<-h.entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// The connection of the aborted request gets closed.
+	err = tt.Stop(ctx)
+	require.ErrorIs(t, err, ErrStopTimeout)
+	require.Error(t, <-reqErr)
+}
+
+func TestDrainerRestart(t *testing.T) {
+	d := newDrainer()
+
+	// A request which outlives the Stop of its start.
+	aborted := d.begin()
+	require.NotNil(t, aborted)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Equal(t, 1, d.wait(ctx))
+	require.Nil(t, d.begin())
+
+	d.reset()
+
+	gen := d.begin()
+	require.NotNil(t, gen)
+
+	// Ending the aborted request doesn't touch the new start.
+	aborted.end()
+	require.Equal(t, 1, d.wait(ctx))
+
+	gen.end()
+	require.Equal(t, 0, d.wait(context.Background()))
+}
+
+func TestDialTimeoutHandshake(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer ln.Close() //nolint:errcheck
+
+	// The peer accepts the connections, but never answers the handshake.
+	go func() {
+		var conns []net.Conn
+
+		defer func() {
+			for _, c := range conns {
+				_ = c.Close() //nolint:errcheck
+			}
+		}()
+
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			conns = append(conns, c)
+		}
+	}()
+
+	d := &poolDialer{pool: &poolConfig{}, conns: newConnSet()}
+
+	start := time.Now()
+
+	_, err = d.DialConnection("tcp", ln.Addr().String(), 100*time.Millisecond, &tls.Config{ServerName: "localhost"}) //nolint:gosec
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.Empty(t, d.conns.conns)
+}
+
+func TestTransportConcurrentClient(t *testing.T) {
+	logger, err := log.New()
+	require.NoError(t, err)
+
+	cfg := orb.NewConfig()
+
+	// newClient runs under the lock of the transport.
+	created := 0
+
+	tt, err := newTransport("test", logger, "http", false, func(t *Transport) (*hclient.Client, error) {
+		created++
+		return hclient.NewClient(t.clientOptions(cfg.PoolSize)...)
+	})
+	require.NoError(t, err)
+
+	tr, ok := tt.Transport.(*Transport)
+	require.True(t, ok)
+
+	errCh := make(chan error, 10)
+
+	for range 10 {
+		go func() {
+			_, err := tr.client()
+			errCh <- err
+		}()
+	}
+
+	for range 10 {
+		require.NoError(t, <-errCh)
+	}
+
+	require.Equal(t, 1, created)
+}
+
+func TestMaxIdlePerHost(t *testing.T) {
+	s := newConnSet()
+	s.open["a:80"] = 2
+
+	// With two open connections a single request leaves both idle.
+	require.False(t, s.begin("a:80", 2))
+	require.True(t, s.begin("a:80", 1))
+	s.end("a:80")
+	s.end("a:80")
+
+	// Three concurrent requests open three connections.
+	s.open["a:80"] = 0
+	require.False(t, s.begin("a:80", 2))
+	require.False(t, s.begin("a:80", 2))
+	require.True(t, s.begin("a:80", 2))
+	require.Equal(t, 3, s.inFlight["a:80"])
+}
+
+func TestConnClose(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close() //nolint:errcheck
+
+	closed := 0
+	c := newConn(client, 4, 4, func() { closed++ })
+
+	// The pool forgets the connection once.
+	require.NoError(t, c.Close())
+	require.NoError(t, c.Close())
+	require.Equal(t, 1, closed)
+}
@@ -0,0 +1,195 @@
+package hertz
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// retryAfterHeader is the header in which servers ask for a delay before the next attempt.
+const retryAfterHeader = "Retry-After"
+
+// idempotentKey is the context key of WithIdempotent.
+type idempotentKey struct{}
+
+// RetryPolicy configures the retries of a transport, see WithRetryPolicy.
+//
+// Requests which failed before they were sent, because no connection could be
+// established, are always retried. Others only when the call has been marked
+// with WithIdempotent: on connection errors, like a reset during a deploy,
+// and on the RetryableStatusCodes.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts. When the server asks
+	// for a longer delay with Retry-After the request doesn't get retried.
+	MaxBackoff time.Duration
+
+	// Multiplier grows the delay after each attempt.
+	Multiplier float64
+
+	// Jitter randomizes the delays by up to this fraction, between 0 and 1.
+	Jitter float64
+
+	// RetryableStatusCodes are the response status codes which get retried.
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy returns a policy with 3 attempts, which retries
+// "429 Too Many Requests" and "503 Service Unavailable".
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       100 * time.Millisecond,
+		MaxBackoff:           2 * time.Second,
+		Multiplier:           2,
+		Jitter:               0.2,
+		RetryableStatusCodes: []int{consts.StatusTooManyRequests, consts.StatusServiceUnavailable},
+	}
+}
+
+// WithRetryPolicy enables retries for the transport.
+func WithRetryPolicy(p RetryPolicy) TransportOption {
+	return func(t *Transport) {
+		p.RetryableStatusCodes = slices.Clone(p.RetryableStatusCodes)
+		t.retry = &p
+	}
+}
+
+// WithIdempotent marks the calls made with the returned context as idempotent,
+// the hertz transports may then retry them after they have been sent, see RetryPolicy.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// isIdempotent returns whether the call has been marked with WithIdempotent.
+func isIdempotent(ctx context.Context) bool {
+	idempotent, _ := ctx.Value(idempotentKey{}).(bool) //nolint:errcheck
+	return idempotent
+}
+
+// attemptResult describes how a single attempt failed.
+type attemptResult struct {
+	// dialFailed is true when no connection could be established, the request hasn't been sent.
+	dialFailed bool
+
+	// connFailed is true when the request failed on the connection, it may have reached the server.
+	connFailed bool
+
+	// status is the status code of the response, 0 without a response.
+	status int
+
+	// retryAfter is the delay the server asked for with the Retry-After header.
+	retryAfter time.Duration
+}
+
+// dialError marks errors of the dialer, requests which failed with it haven't been sent.
+type dialError struct {
+	err error
+}
+
+func (e *dialError) Error() string {
+	return e.err.Error()
+}
+
+func (e *dialError) Unwrap() error {
+	return e.err
+}
+
+// isDialError returns whether err comes from the dialer.
+func isDialError(err error) bool {
+	var de *dialError
+	return errors.As(err, &de)
+}
+
+// retryable returns whether another attempt should follow the failed one.
+func (p *RetryPolicy) retryable(attempt int, idempotent bool, res *attemptResult) bool {
+	if p == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+
+	switch {
+	case res.dialFailed:
+		return true
+	case !idempotent:
+		return false
+	case res.connFailed:
+		return true
+	default:
+		return slices.Contains(p.RetryableStatusCodes, res.status)
+	}
+}
+
+// backoff returns the delay before the next attempt, false
+// when the server asked for a delay above MaxBackoff.
+func (p *RetryPolicy) backoff(attempt int, retryAfter time.Duration) (time.Duration, bool) {
+	if retryAfter > 0 {
+		return retryAfter, p.MaxBackoff <= 0 || retryAfter <= p.MaxBackoff
+	}
+
+	d := float64(p.InitialBackoff) * math.Pow(max(p.Multiplier, 1), float64(attempt-1))
+	if p.MaxBackoff > 0 {
+		d = min(d, float64(p.MaxBackoff))
+	}
+
+	if p.Jitter > 0 {
+		d *= 1 + min(p.Jitter, 1)*(2*rand.Float64()-1) //nolint:gosec
+	}
+
+	return time.Duration(d), true
+}
+
+// parseRetryAfter parses the Retry-After header, either seconds or a HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0)
+	}
+
+	return 0
+}
+
+// waitRetry waits for the delay, it returns false without waiting when the
+// context would be done by then.
+func waitRetry(ctx context.Context, attempt int, delay time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+		return false
+	}
+
+	trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+		attribute.Int("retry.attempt", attempt+1),
+		attribute.Int64("retry.delay_ms", delay.Milliseconds()),
+	))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
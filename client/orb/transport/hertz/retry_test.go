@@ -0,0 +1,227 @@
+package hertz
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/network/dialer"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/client"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/log"
+	"github.com/go-orb/go-orb/util/metadata"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/go-orb/plugins/client/orb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-orb/plugins-experimental/server/hertz"
+)
+
+// flakyRoute fails the first requests with a status code, its calls get counted.
+type flakyRoute struct {
+	calls      atomic.Int32
+	failures   int32
+	code       int
+	retryAfter string
+}
+
+func (r *flakyRoute) handle(_ context.Context, ctx *app.RequestContext) {
+	if r.calls.Add(1) <= r.failures {
+		if r.retryAfter != "" {
+			ctx.Header(retryAfterHeader, r.retryAfter)
+		}
+
+		ctx.String(r.code, consts.StatusMessage(r.code))
+
+		return
+	}
+
+	ctx.Data(consts.StatusOK, codecs.MimeJSON, []byte(`{"text":"ok"}`))
+}
+
+// setupRetryServer starts an entrypoint with the routes.
+func setupRetryServer(t *testing.T, routes map[string]*flakyRoute) (string, log.Logger) {
+	t.Helper()
+
+	ep, logger := setupTestServer(t, func(s *hertz.Server) {
+		for path, route := range routes {
+			s.Router().POST(path, route.handle)
+		}
+	}, hertz.WithInsecure())
+
+	return ep.Address(), logger
+}
+
+func TestRetry(t *testing.T) {
+	routes := map[string]*flakyRoute{
+		"/test.Retry/Unavailable":   {failures: 2, code: consts.StatusServiceUnavailable},
+		"/test.Retry/NotIdempotent": {failures: 2, code: consts.StatusServiceUnavailable},
+		"/test.Retry/BadRequest":    {failures: 2, code: consts.StatusBadRequest},
+		"/test.Retry/RetryAfter":    {failures: 1, code: consts.StatusTooManyRequests, retryAfter: "0"},
+		"/test.Retry/RetryLater":    {failures: 1, code: consts.StatusTooManyRequests, retryAfter: "60"},
+	}
+
+	address, logger := setupRetryServer(t, routes)
+
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = 10 * time.Millisecond
+
+	cfg := orb.NewConfig()
+
+	tt, err := NewHTTPTransport(logger, &cfg, WithRetryPolicy(policy))
+	require.NoError(t, err)
+
+	request := func(endpoint string, idempotent bool) error {
+		ctx := context.Background()
+		if idempotent {
+			ctx = WithIdempotent(ctx)
+		}
+
+		return tt.Request(
+			ctx,
+			client.RequestInfos{Service: "test.retry", Endpoint: endpoint, Address: address},
+			&streamMsg{Text: "hello"},
+			&streamMsg{},
+			&client.CallOptions{ContentType: codecs.MimeJSON},
+		)
+	}
+
+	tests := []struct {
+		name       string
+		endpoint   string
+		idempotent bool
+		calls      int32
+		err        error
+	}{
+		{name: "retryable status", endpoint: "/test.Retry/Unavailable", idempotent: true, calls: 3},
+		{name: "not idempotent", endpoint: "/test.Retry/NotIdempotent", calls: 1, err: orberrors.ErrUnavailable},
+		{name: "not retryable", endpoint: "/test.Retry/BadRequest", idempotent: true, calls: 1, err: orberrors.ErrBadRequest},
+		{name: "retry after", endpoint: "/test.Retry/RetryAfter", idempotent: true, calls: 2},
+		{
+			name:       "retry after above max backoff",
+			endpoint:   "/test.Retry/RetryLater",
+			idempotent: true,
+			calls:      1,
+			err:        orberrors.HTTP(consts.StatusTooManyRequests),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := request(tc.endpoint, tc.idempotent)
+			if tc.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.err)
+			}
+
+			require.Equal(t, tc.calls, routes[tc.endpoint].calls.Load())
+		})
+	}
+}
+
+func TestRetryDeadline(t *testing.T) {
+	route := &flakyRoute{failures: 10, code: consts.StatusServiceUnavailable}
+	address, logger := setupRetryServer(t, map[string]*flakyRoute{"/test.Retry/Slow": route})
+
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Second
+
+	cfg := orb.NewConfig()
+
+	tt, err := NewHTTPTransport(logger, &cfg, WithRetryPolicy(policy))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(WithIdempotent(context.Background()), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	err = tt.Request(
+		ctx,
+		client.RequestInfos{Service: "test.retry", Endpoint: "/test.Retry/Slow", Address: address},
+		&streamMsg{Text: "hello"},
+		&streamMsg{},
+		&client.CallOptions{ContentType: codecs.MimeJSON},
+	)
+	require.ErrorIs(t, err, orberrors.ErrUnavailable)
+
+	// The backoff exceeds the deadline, so there's no point in waiting for it.
+	require.Less(t, time.Since(start), 200*time.Millisecond)
+	require.Equal(t, int32(1), route.calls.Load())
+}
+
+func TestRetryDialError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	address := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	_, err = newUnixDialer(dialer.DefaultDialer()).DialConnection("tcp", address, time.Second, nil)
+	require.Error(t, err)
+	require.True(t, isDialError(err))
+
+	policy := DefaultRetryPolicy()
+
+	require.True(t, policy.retryable(1, false, &attemptResult{dialFailed: true}))
+	require.False(t, policy.retryable(1, false, &attemptResult{connFailed: true}))
+	require.True(t, policy.retryable(1, true, &attemptResult{connFailed: true}))
+	require.False(t, policy.retryable(policy.MaxAttempts, true, &attemptResult{dialFailed: true}))
+}
+
+func TestIdempotent(t *testing.T) {
+	ctx := context.Background()
+	require.False(t, isIdempotent(ctx))
+
+	ctx = WithIdempotent(ctx)
+	require.True(t, isIdempotent(ctx))
+
+	// The mark isn't metadata, it doesn't get sent.
+	_, ok := metadata.Outgoing(ctx)
+	require.False(t, ok)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     300 * time.Millisecond,
+		Multiplier:     2,
+	}
+
+	for attempt, want := range []time.Duration{100, 200, 300, 300} {
+		d, ok := policy.backoff(attempt+1, 0)
+		require.True(t, ok)
+		require.Equal(t, want*time.Millisecond, d)
+	}
+
+	policy.Jitter = 0.5
+
+	for range 100 {
+		d, _ := policy.backoff(1, 0)
+		require.GreaterOrEqual(t, d, 50*time.Millisecond)
+		require.LessOrEqual(t, d, 150*time.Millisecond)
+	}
+
+	d, ok := policy.backoff(1, 200*time.Millisecond)
+	require.True(t, ok)
+	require.Equal(t, 200*time.Millisecond, d)
+
+	_, ok = policy.backoff(1, time.Second)
+	require.False(t, ok)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	require.Equal(t, 3*time.Second, parseRetryAfter("3"))
+	require.Equal(t, time.Duration(0), parseRetryAfter(""))
+	require.Equal(t, time.Duration(0), parseRetryAfter("soon"))
+
+	d := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	require.InDelta(t, time.Minute, d, float64(2*time.Second))
+}
@@ -0,0 +1,375 @@
+package hertz
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/go-orb/go-orb/client"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/util/metadata"
+	"github.com/go-orb/go-orb/util/orberrors"
+)
+
+// Streams are sent as a sequence of frames in both directions, each frame is
+// a 1 byte flag, followed by the 4 byte big endian length of the payload and
+// the payload itself. The server ends the stream with a frame flagged
+// frameFlagEnd which contains the status as JSON errorBody, code 200 means success.
+const (
+	frameHeaderLen = 5
+
+	frameFlagEnd byte = 0x80
+)
+
+// DefaultMaxRecvMsgSize is the max size of a received stream message when
+// the call options don't set MaxCallRecvMsgSize.
+const DefaultMaxRecvMsgSize = 4 * 1024 * 1024
+
+// ErrMessageTooLarge is returned when a stream message exceeds the max message size.
+var ErrMessageTooLarge = errors.New("stream message too large")
+
+var _ client.StreamIface[any, any] = (*clientStream)(nil)
+
+// clientStream is a bidirectional stream over HTTP/2.
+type clientStream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	contentType string
+	opts        *client.CallOptions
+
+	// bodyWriter feeds the request body.
+	bodyWriter *io.PipeWriter
+
+	// respDone gets closed once the response headers arrived or the request failed.
+	respDone chan struct{}
+	respErr  error
+	hRes     *protocol.Response
+	body     io.Reader
+
+	// recvErr is the final error returned by Recv once the stream ended.
+	recvErr error
+
+	// release frees the slot of the stream in the drainer of the transport.
+	release func()
+
+	sendLock sync.Mutex
+	closed   bool
+}
+
+// Stream creates a bidirectional stream to the service endpoint.
+// Streaming requires a HTTP/2 transport, either hertzh2c or hertzh2.
+func (t *Transport) Stream(ctx context.Context, infos client.RequestInfos, opts *client.CallOptions,
+) (client.StreamIface[any, any], error) {
+	if !t.http2 {
+		return nil, orberrors.HTTP(501).Wrap(client.ErrStreamNotSupported)
+	}
+
+	gen := t.drain.begin()
+	if gen == nil {
+		return nil, orberrors.ErrUnavailable.Wrap(ErrTransportStopped)
+	}
+
+	hclient, err := t.client()
+	if err != nil {
+		gen.end()
+		return nil, orberrors.From(err)
+	}
+
+	var cancel context.CancelFunc
+	if opts.StreamTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.StreamTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	pr, pw := io.Pipe()
+
+	// Create a hertz request with a streaming body.
+	hReq := &protocol.Request{}
+	hReq.SetMethod(consts.MethodPost)
+	hReq.SetBodyStream(pr, -1)
+	hReq.Header.SetContentTypeBytes([]byte(opts.ContentType))
+	hReq.Header.Set("Accept", opts.ContentType)
+	hReq.SetRequestURI(fmt.Sprintf("%s://%s%s", t.scheme, requestHost(infos.Address), infos.Endpoint))
+
+	// Set metadata key=value to request headers.
+	md, ok := metadata.Outgoing(ctx)
+	if ok {
+		for name, value := range md {
+			hReq.Header.Set(name, value)
+		}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		setTimeout(hReq, max(time.Until(deadline), 1))
+	}
+
+	stream := &clientStream{
+		ctx:         ctx,
+		cancel:      cancel,
+		contentType: opts.ContentType,
+		opts:        opts,
+		bodyWriter:  pw,
+		respDone:    make(chan struct{}),
+		hRes:        &protocol.Response{},
+		release:     sync.OnceFunc(gen.end),
+	}
+
+	// Do returns after the response headers arrived, the body gets streamed in both directions.
+	go func() {
+		defer close(stream.respDone)
+
+		if err := hclient.Do(ctx, hReq, stream.hRes); err != nil {
+			stream.respErr = orberrors.From(err)
+			pr.CloseWithError(err)
+
+			return
+		}
+
+		stream.respErr = stream.readResponse()
+	}()
+
+	// Cancel the request when the context is done, Stop waits for the stream until then.
+	go func() {
+		<-ctx.Done()
+		pw.CloseWithError(ctx.Err())
+		stream.release()
+	}()
+
+	return stream, nil
+}
+
+// readResponse checks the response status and copies the response metadata.
+func (s *clientStream) readResponse() error {
+	if s.opts.ResponseMetadata != nil {
+		for _, v := range s.hRes.Header.GetHeaders() {
+			k := string(v.GetKey())
+
+			// Skip std headers.
+			if slices.Contains(stdHeaders, k) {
+				continue
+			}
+
+			s.opts.ResponseMetadata[strings.ToLower(k)] = string(v.GetValue())
+		}
+	}
+
+	if s.hRes.StatusCode() != consts.StatusOK {
+		return decodeError(s.hRes.StatusCode(), string(s.hRes.Header.ContentType()), s.hRes.Body())
+	}
+
+	s.body = s.hRes.BodyStream()
+	if s.body == nil {
+		s.body = bytes.NewReader(s.hRes.Body())
+	}
+
+	return nil
+}
+
+// Send encodes and sends a message to the server.
+func (s *clientStream) Send(msg any) error {
+	codec, err := codecs.GetEncoder(s.contentType, msg)
+	if err != nil {
+		return orberrors.ErrBadRequest.Wrap(err)
+	}
+
+	payload, err := codec.Marshal(msg)
+	if err != nil {
+		return orberrors.ErrBadRequest.Wrap(err)
+	}
+
+	if s.opts.MaxCallSendMsgSize > 0 && len(payload) > s.opts.MaxCallSendMsgSize {
+		return orberrors.HTTP(consts.StatusRequestEntityTooLarge).Wrap(ErrMessageTooLarge)
+	}
+
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+
+	if s.closed {
+		return orberrors.ErrBadRequest.Wrap(io.ErrClosedPipe)
+	}
+
+	if err := s.ctx.Err(); err != nil {
+		return orberrors.From(err)
+	}
+
+	if err := writeFrame(s.bodyWriter, 0, payload); err != nil {
+		if ctxErr := s.ctx.Err(); ctxErr != nil {
+			return orberrors.From(ctxErr)
+		}
+
+		return orberrors.From(err)
+	}
+
+	return nil
+}
+
+// Recv receives and decodes the next message from the server,
+// it returns io.EOF once the server has successfully ended the stream.
+func (s *clientStream) Recv(msg any) error {
+	if s.recvErr != nil {
+		return s.recvErr
+	}
+
+	select {
+	case <-s.respDone:
+	case <-s.ctx.Done():
+		return orberrors.From(s.ctx.Err())
+	}
+
+	if s.respErr != nil {
+		s.recvErr = s.respErr
+		s.end()
+
+		return s.recvErr
+	}
+
+	flags, payload, err := readFrame(s.body, s.opts.MaxCallRecvMsgSize)
+	if err != nil {
+		switch {
+		case s.ctx.Err() != nil:
+			s.recvErr = orberrors.From(s.ctx.Err())
+		case errors.Is(err, io.EOF):
+			// The server must end the stream with an end frame.
+			s.recvErr = orberrors.From(io.ErrUnexpectedEOF)
+		default:
+			s.recvErr = orberrors.From(err)
+		}
+
+		s.end()
+
+		return s.recvErr
+	}
+
+	if flags&frameFlagEnd != 0 {
+		// The server is done, release the request.
+		s.recvErr = decodeStatus(payload)
+		s.end()
+
+		return s.recvErr
+	}
+
+	codec, err := codecs.GetDecoder(s.contentType, msg)
+	if err != nil {
+		return orberrors.ErrBadRequest.Wrap(err)
+	}
+
+	if err := codec.Unmarshal(payload, msg); err != nil {
+		return orberrors.ErrBadRequest.Wrap(err)
+	}
+
+	return nil
+}
+
+// CloseSend closes the send direction of the stream, the server sees io.EOF.
+func (s *clientStream) CloseSend() error {
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+
+	return s.bodyWriter.Close()
+}
+
+// Close closes the stream in both directions and cancels the request.
+func (s *clientStream) Close() error {
+	err := s.CloseSend()
+
+	s.end()
+
+	return err
+}
+
+// end cancels the request and releases the stream for Stop,
+// it's safe to call it more than once.
+func (s *clientStream) end() {
+	s.cancel()
+	s.release()
+}
+
+// Context returns the context of the stream.
+func (s *clientStream) Context() context.Context {
+	return s.ctx
+}
+
+// decodeStatus returns io.EOF for a successful status and an orberror else.
+func decodeStatus(payload []byte) error {
+	status := &errorBody{}
+	if err := json.Unmarshal(payload, status); err != nil {
+		return orberrors.ErrInternalServerError.Wrap(err)
+	}
+
+	if status.Code == consts.StatusOK {
+		return io.EOF
+	}
+
+	err := status.toError(0)
+	if status.Code == consts.StatusRequestEntityTooLarge {
+		return ErrRequestEntityTooLarge.Wrap(err)
+	}
+
+	if orbe, ok := err.(*orberrors.Error); ok { //nolint:errorlint
+		return orbe
+	}
+
+	return orberrors.ErrInternalServerError.Wrap(err)
+}
+
+// readFrame reads a single frame of up to maxSize bytes, DefaultMaxRecvMsgSize if it's 0.
+func readFrame(r io.Reader, maxSize int) (byte, []byte, error) {
+	var header [frameHeaderLen]byte
+
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	if maxSize <= 0 {
+		maxSize = DefaultMaxRecvMsgSize
+	}
+
+	// Check the length before allocating, it comes from the wire.
+	length := binary.BigEndian.Uint32(header[1:])
+	if int64(length) > int64(maxSize) {
+		return 0, nil, orberrors.HTTP(consts.StatusRequestEntityTooLarge).Wrap(ErrMessageTooLarge)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+
+		return 0, nil, err
+	}
+
+	return header[0], payload, nil
+}
+
+// writeFrame writes a single frame.
+func writeFrame(w io.Writer, flags byte, payload []byte) error {
+	frame := make([]byte, frameHeaderLen+len(payload))
+
+	frame[0] = flags
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload))) //nolint:gosec
+	copy(frame[frameHeaderLen:], payload)
+
+	_, err := w.Write(frame)
+
+	return err
+}
@@ -0,0 +1,227 @@
+package hertz
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-orb/go-orb/client"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/log"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/go-orb/plugins/client/orb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-orb/plugins-experimental/server/hertz"
+)
+
+const streamEndpoint = "/test.Streams/Echo"
+
+type streamMsg struct {
+	Text string `json:"text"`
+}
+
+// echoStream echos all messages back, it fails on the text "fail".
+func echoStream(stream hertz.ServerStream[streamMsg, streamMsg]) error {
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if msg.Text == "fail" {
+			return orberrors.ErrBadRequest
+		}
+
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func setupStreamServer(t *testing.T) (string, log.Logger) {
+	t.Helper()
+
+	ep, logger := setupTestServer(t, func(s *hertz.Server) {
+		s.Router().POST(streamEndpoint, hertz.NewStreamHandler(s, echoStream, "test.Streams", "Echo"))
+	}, hertz.WithInsecure(), hertz.WithAllowH2C())
+
+	return ep.Address(), logger
+}
+
+func newStream(ctx context.Context, t *testing.T, address string, logger log.Logger) client.StreamIface[any, any] {
+	t.Helper()
+
+	cfg := orb.NewConfig()
+
+	tt, err := NewH2CTransport(logger, &cfg)
+	require.NoError(t, err)
+
+	stream, err := tt.Stream(
+		ctx,
+		client.RequestInfos{Service: "test.stream", Endpoint: streamEndpoint, Address: address},
+		&client.CallOptions{ContentType: codecs.MimeJSON, ResponseMetadata: map[string]string{}},
+	)
+	require.NoError(t, err)
+
+	return stream
+}
+
+func TestStreamEcho(t *testing.T) {
+	address, logger := setupStreamServer(t)
+	stream := newStream(context.Background(), t, address, logger)
+
+	for _, text := range []string{"a", "b", "c"} {
+		require.NoError(t, stream.Send(&streamMsg{Text: text}))
+
+		resp := &streamMsg{}
+		require.NoError(t, stream.Recv(resp))
+		require.Equal(t, text, resp.Text)
+	}
+
+	require.NoError(t, stream.CloseSend())
+	require.ErrorIs(t, stream.Recv(&streamMsg{}), io.EOF)
+	require.NoError(t, stream.Close())
+}
+
+func TestStreamError(t *testing.T) {
+	address, logger := setupStreamServer(t)
+	stream := newStream(context.Background(), t, address, logger)
+
+	require.NoError(t, stream.Send(&streamMsg{Text: "fail"}))
+
+	err := stream.Recv(&streamMsg{})
+	orbe, ok := orberrors.As(err)
+	require.True(t, ok)
+	require.Equal(t, orberrors.ErrBadRequest.Code, orbe.Code)
+	require.NoError(t, stream.Close())
+}
+
+func TestStreamCancel(t *testing.T) {
+	address, logger := setupStreamServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := newStream(ctx, t, address, logger)
+
+	require.NoError(t, stream.Send(&streamMsg{Text: "a"}))
+	require.NoError(t, stream.Recv(&streamMsg{}))
+
+	cancel()
+
+	require.Error(t, stream.Recv(&streamMsg{}))
+	require.Error(t, stream.Send(&streamMsg{Text: "b"}))
+}
+
+func TestStreamNotSupported(t *testing.T) {
+	logger, err := log.New()
+	require.NoError(t, err)
+
+	cfg := orb.NewConfig()
+
+	tt, err := NewHTTPTransport(logger, &cfg)
+	require.NoError(t, err)
+
+	_, err = tt.Stream(context.Background(), client.RequestInfos{}, &client.CallOptions{})
+	require.ErrorIs(t, err, client.ErrStreamNotSupported)
+}
+
+// The stream releases its slot for Stop once it ended, even without Close.
+func TestStreamEndReleasesStop(t *testing.T) {
+	address, logger := setupStreamServer(t)
+
+	tests := []struct {
+		name     string
+		endpoint string
+		end      func(t *testing.T, stream client.StreamIface[any, any])
+	}{
+		{
+			name:     "end frame",
+			endpoint: streamEndpoint,
+			end: func(t *testing.T, stream client.StreamIface[any, any]) {
+				t.Helper()
+
+				require.NoError(t, stream.CloseSend())
+				require.ErrorIs(t, stream.Recv(&streamMsg{}), io.EOF)
+			},
+		},
+		{
+			name:     "response error",
+			endpoint: "/test.Streams/Unknown",
+			end: func(t *testing.T, stream client.StreamIface[any, any]) {
+				t.Helper()
+
+				err := stream.Recv(&streamMsg{})
+				require.Error(t, err)
+				require.NotErrorIs(t, err, io.EOF)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := orb.NewConfig()
+
+			tt, err := NewH2CTransport(logger, &cfg)
+			require.NoError(t, err)
+
+			stream, err := tt.Stream(
+				context.Background(),
+				client.RequestInfos{Service: "test.stream", Endpoint: tc.endpoint, Address: address},
+				&client.CallOptions{ContentType: codecs.MimeJSON},
+			)
+			require.NoError(t, err)
+
+			tc.end(t, stream)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			require.NoError(t, tt.Stop(ctx))
+
+			// Close after the end doesn't release the slot twice.
+			require.NoError(t, stream.Close())
+
+			tr, ok := tt.Transport.(*Transport)
+			require.True(t, ok)
+
+			gen := tr.drain.current()
+
+			gen.mu.Lock()
+			defer gen.mu.Unlock()
+
+			require.Zero(t, gen.inFlight)
+		})
+	}
+}
+
+func TestReadFrameLimit(t *testing.T) {
+	frame := func(length uint32) *bytes.Reader {
+		header := make([]byte, frameHeaderLen)
+		binary.BigEndian.PutUint32(header[1:], length)
+
+		return bytes.NewReader(header)
+	}
+
+	// Without a max size the default applies before anything gets allocated.
+	_, _, err := readFrame(frame(math.MaxUint32), 0)
+	require.ErrorIs(t, err, ErrMessageTooLarge)
+
+	_, _, err = readFrame(frame(DefaultMaxRecvMsgSize+1), 0)
+	require.ErrorIs(t, err, ErrMessageTooLarge)
+
+	_, _, err = readFrame(frame(11), 10)
+	require.ErrorIs(t, err, ErrMessageTooLarge)
+
+	// The length is fine, but the payload is missing.
+	_, _, err = readFrame(frame(10), 10)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,81 @@
+package hertz
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/go-orb/plugins/client/orb"
+)
+
+// tlsOptions are the TLS settings of the TransportOptions below,
+// they override those of the orb clients TLS config.
+type tlsOptions struct {
+	rootCAs            *x509.CertPool
+	certificates       []tls.Certificate
+	serverName         string
+	insecureSkipVerify bool
+}
+
+// WithRootCAs sets the CAs which verify the server certificates,
+// for example the private CA of self-signed server certificates.
+func WithRootCAs(pool *x509.CertPool) TransportOption {
+	return func(t *Transport) {
+		t.tls.rootCAs = pool
+	}
+}
+
+// WithClientCertificates sets the certificates sent to servers which request one, for mTLS.
+func WithClientCertificates(certs ...tls.Certificate) TransportOption {
+	return func(t *Transport) {
+		t.tls.certificates = append(t.tls.certificates, certs...)
+	}
+}
+
+// WithServerName sets the name the server certificates get verified against,
+// it defaults to the host of the address.
+func WithServerName(name string) TransportOption {
+	return func(t *Transport) {
+		t.tls.serverName = name
+	}
+}
+
+// WithInsecureSkipVerify accepts any server certificate.
+//
+// WARNING: this makes the connections open to man-in-the-middle attacks,
+// only use it for tests.
+func WithInsecureSkipVerify() TransportOption {
+	return func(t *Transport) {
+		t.tls.insecureSkipVerify = true
+	}
+}
+
+// tlsConfig returns a copy of the orb clients TLS config with the given ALPN
+// protocols and the TLS options of the transport applied.
+func (t *Transport) tlsConfig(cfg *orb.Config, nextProtos ...string) *tls.Config {
+	var tlsConfig *tls.Config
+	if cfg.TLSConfig != nil {
+		tlsConfig = cfg.TLSConfig.Clone()
+	} else {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	tlsConfig.NextProtos = nextProtos
+
+	if t.tls.rootCAs != nil {
+		tlsConfig.RootCAs = t.tls.rootCAs
+	}
+
+	if len(t.tls.certificates) > 0 {
+		tlsConfig.Certificates = t.tls.certificates
+	}
+
+	if t.tls.serverName != "" {
+		tlsConfig.ServerName = t.tls.serverName
+	}
+
+	if t.tls.insecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	return tlsConfig
+}
@@ -0,0 +1,238 @@
+package hertz
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/client"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/log"
+	"github.com/go-orb/plugins/client/orb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-orb/plugins-experimental/server/hertz"
+)
+
+const (
+	tlsEndpoint   = "/test.TLS/Echo"
+	tlsServerName = "server.test"
+)
+
+// testCA is a private certificate authority.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+// issue creates a certificate signed by the CA for the usage, it's valid for
+// tlsServerName and 127.0.0.1.
+func (ca *testCA) issue(t *testing.T, usage x509.ExtKeyUsage) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: tlsServerName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     []string{tlsServerName},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// setupTLSServer starts a HTTPS entrypoint with the certificate of the CA,
+// with clientCAs it requires client certificates signed by them.
+func setupTLSServer(t *testing.T, ca *testCA, clientCAs *x509.CertPool) (string, log.Logger) {
+	t.Helper()
+
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{ca.issue(t, x509.ExtKeyUsageServerAuth)},
+	}
+
+	if clientCAs != nil {
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	ep, logger := setupTestServer(t, func(s *hertz.Server) {
+		s.Router().POST(tlsEndpoint, func(_ context.Context, ctx *app.RequestContext) {
+			ctx.Data(consts.StatusOK, codecs.MimeJSON, []byte(`{"text":"ok"}`))
+		})
+	}, hertz.WithAddress("127.0.0.1:0"), hertz.WithTLS(tlsConfig))
+
+	return ep.Address(), logger
+}
+
+func tlsRequest(t *testing.T, tt orb.TransportType, address string) error {
+	t.Helper()
+
+	defer func() {
+		require.NoError(t, tt.Stop(context.Background()))
+	}()
+
+	rsp := &streamMsg{}
+
+	err := tt.Request(
+		context.Background(),
+		client.RequestInfos{Service: "test.TLS", Endpoint: tlsEndpoint, Address: address},
+		&streamMsg{Text: "hello"},
+		rsp,
+		&client.CallOptions{ContentType: codecs.MimeJSON},
+	)
+	if err == nil {
+		require.Equal(t, "ok", rsp.Text)
+	}
+
+	return err
+}
+
+func TestTLSOptions(t *testing.T) {
+	ca := newTestCA(t)
+	address, logger := setupTLSServer(t, ca, nil)
+
+	tests := []struct {
+		name string
+		opts []TransportOption
+		ok   bool
+	}{
+		{name: "root CAs", opts: []TransportOption{WithRootCAs(ca.pool)}, ok: true},
+		{name: "unknown CA", opts: nil, ok: false},
+		{name: "insecure skip verify", opts: []TransportOption{WithInsecureSkipVerify()}, ok: true},
+		{name: "server name", opts: []TransportOption{WithRootCAs(ca.pool), WithServerName(tlsServerName)}, ok: true},
+		{name: "server name mismatch", opts: []TransportOption{WithRootCAs(ca.pool), WithServerName("other.test")}, ok: false},
+	}
+
+	transports := map[string]func(log.Logger, *orb.Config, ...TransportOption) (orb.TransportType, error){
+		"hertzhttps": NewHTTPSTransport,
+		"hertzh2":    NewH2Transport,
+	}
+
+	for name, newTransport := range transports {
+		for _, tc := range tests {
+			t.Run(name+"/"+tc.name, func(t *testing.T) {
+				cfg := orb.NewConfig()
+
+				tt, err := newTransport(logger, &cfg, tc.opts...)
+				require.NoError(t, err)
+
+				err = tlsRequest(t, tt, address)
+				if tc.ok {
+					require.NoError(t, err)
+				} else {
+					require.Error(t, err)
+				}
+			})
+		}
+	}
+}
+
+func TestTLSClientCertificates(t *testing.T) {
+	ca := newTestCA(t)
+	clientCA := newTestCA(t)
+	address, logger := setupTLSServer(t, ca, clientCA.pool)
+
+	tests := []struct {
+		name string
+		cert *tls.Certificate
+		ok   bool
+	}{
+		{name: "no certificate", ok: false},
+		{name: "unknown CA", cert: ptr(ca.issue(t, x509.ExtKeyUsageClientAuth)), ok: false},
+		{name: "client CA", cert: ptr(clientCA.issue(t, x509.ExtKeyUsageClientAuth)), ok: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := []TransportOption{WithRootCAs(ca.pool)}
+			if tc.cert != nil {
+				opts = append(opts, WithClientCertificates(*tc.cert))
+			}
+
+			cfg := orb.NewConfig()
+
+			tt, err := NewHTTPSTransport(logger, &cfg, opts...)
+			require.NoError(t, err)
+
+			err = tlsRequest(t, tt, address)
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+// The TLS options override the TLS config of the orb client.
+func TestTLSConfigOverride(t *testing.T) {
+	ca := newTestCA(t)
+	address, logger := setupTLSServer(t, ca, nil)
+
+	cfg := orb.NewConfig()
+	cfg.TLSConfig = &tls.Config{ //nolint:gosec
+		MinVersion: tls.VersionTLS12,
+		ServerName: "other.test",
+	}
+
+	tt, err := NewHTTPSTransport(logger, &cfg, WithRootCAs(ca.pool), WithServerName(tlsServerName))
+	require.NoError(t, err)
+	require.NoError(t, tlsRequest(t, tt, address))
+
+	// The config of the orb client stays untouched.
+	require.Nil(t, cfg.TLSConfig.RootCAs)
+	require.Equal(t, "other.test", cfg.TLSConfig.ServerName)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,139 @@
+package hertz
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/go-orb/go-orb/client"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the spans.
+const tracerName = "github.com/go-orb/plugins-experimental/client/orb/transport/hertz"
+
+// Span attributes, the names follow the OpenTelemetry semantic conventions.
+const (
+	attrRPCSystem        = attribute.Key("rpc.system")
+	attrRPCService       = attribute.Key("rpc.service")
+	attrRPCMethod        = attribute.Key("rpc.method")
+	attrServerAddress    = attribute.Key("server.address")
+	attrStatusCode       = attribute.Key("http.response.status_code")
+	attrRequestBodySize  = attribute.Key("http.request.body.size")
+	attrResponseBodySize = attribute.Key("http.response.body.size")
+)
+
+// tracingOptions are the tracing settings of the TransportOptions below.
+type tracingOptions struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+// WithTracerProvider sets the provider which creates the client spans,
+// it defaults to the global provider of go.opentelemetry.io/otel.
+func WithTracerProvider(tp trace.TracerProvider) TransportOption {
+	return func(t *Transport) {
+		t.tracing.provider = tp
+	}
+}
+
+// WithPropagator sets the propagator which injects the trace context into the
+// request headers, it defaults to W3C Trace Context (traceparent and tracestate).
+func WithPropagator(p propagation.TextMapPropagator) TransportOption {
+	return func(t *Transport) {
+		t.tracing.propagator = p
+	}
+}
+
+var _ propagation.TextMapCarrier = (*headerCarrier)(nil)
+
+// headerCarrier adapts the request headers for propagators.
+type headerCarrier struct {
+	h *protocol.RequestHeader
+}
+
+func (c headerCarrier) Get(key string) string {
+	return c.h.Get(key)
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c.h.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := []string{}
+
+	c.h.VisitAll(func(k, _ []byte) {
+		keys = append(keys, string(k))
+	})
+
+	return keys
+}
+
+// tracer returns the tracer of WithTracerProvider.
+func (t *Transport) tracer() trace.Tracer {
+	tp := t.tracing.provider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	return tp.Tracer(tracerName)
+}
+
+// propagator returns the propagator of WithPropagator.
+func (t *Transport) propagator() propagation.TextMapPropagator {
+	if t.tracing.propagator == nil {
+		return propagation.TraceContext{}
+	}
+
+	return t.tracing.propagator
+}
+
+// startSpan starts the client span of a request.
+func (t *Transport) startSpan(ctx context.Context, infos client.RequestInfos) (context.Context, trace.Span) {
+	name := strings.TrimPrefix(infos.Endpoint, "/")
+
+	attrs := []attribute.KeyValue{
+		attrRPCSystem.String("orb"),
+		attrRPCService.String(infos.Service),
+		attrServerAddress.String(infos.Address),
+	}
+
+	if _, method, ok := strings.Cut(name, "/"); ok {
+		attrs = append(attrs, attrRPCMethod.String(method))
+	}
+
+	return t.tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// injectSpan sets the trace context headers of the span in ctx,
+// they replace those which came with the outgoing metadata.
+func (t *Transport) injectSpan(ctx context.Context, hReq *protocol.Request) {
+	t.propagator().Inject(ctx, headerCarrier{&hReq.Header})
+}
+
+// traceResponse adds the status and sizes of the exchange to the span in ctx.
+func traceResponse(ctx context.Context, hReq *protocol.Request, hRes *protocol.Response) {
+	trace.SpanFromContext(ctx).SetAttributes(
+		attrStatusCode.Int(hRes.StatusCode()),
+		attrRequestBodySize.Int(len(hReq.Body())),
+		attrResponseBodySize.Int(len(hRes.Body())),
+	)
+}
+
+// endSpan marks the span as failed when err isn't nil and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
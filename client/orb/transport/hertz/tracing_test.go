@@ -0,0 +1,144 @@
+package hertz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-orb/go-orb/client"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/go-orb/plugins/client/orb"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/go-orb/plugins-experimental/server/hertz"
+)
+
+// spanAttr returns the value of an attribute of the span.
+func spanAttr(t *testing.T, span tracetest.SpanStub, key string) attribute.Value {
+	t.Helper()
+
+	for _, attr := range span.Attributes {
+		if string(attr.Key) == key {
+			return attr.Value
+		}
+	}
+
+	t.Fatalf("span '%s' has no attribute '%s'", span.Name, key)
+
+	return attribute.Value{}
+}
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	var handlerSpan trace.SpanContext
+
+	ep, logger := setupTestServer(t, func(s *hertz.Server) {
+		s.Router().POST("/test.Tracing/Echo", hertz.NewGRPCHandler(s,
+			func(ctx context.Context, req *streamMsg) (*streamMsg, error) {
+				handlerSpan = trace.SpanContextFromContext(ctx)
+				return req, nil
+			}, "test.Tracing", "Echo"))
+		s.Router().POST("/test.Tracing/Fail", hertz.NewGRPCHandler(s,
+			func(_ context.Context, _ *streamMsg) (*streamMsg, error) {
+				return nil, orberrors.ErrUnavailable
+			}, "test.Tracing", "Fail"))
+	}, hertz.WithInsecure(), hertz.WithTracerProvider(tp))
+
+	ctx := context.Background()
+	cfg := orb.NewConfig()
+
+	tt, err := NewHTTPTransport(logger, &cfg, WithTracerProvider(tp))
+	require.NoError(t, err)
+
+	request := func(endpoint string) error {
+		return tt.Request(
+			ctx,
+			client.RequestInfos{Service: "test.tracing", Endpoint: endpoint, Address: ep.Address()},
+			&streamMsg{Text: "hello"},
+			&streamMsg{},
+			&client.CallOptions{ContentType: codecs.MimeJSON},
+		)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		exporter.Reset()
+
+		require.NoError(t, request("/test.Tracing/Echo"))
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 2)
+
+		serverSpan, clientSpan := spans[0], spans[1]
+
+		require.Equal(t, trace.SpanKindServer, serverSpan.SpanKind)
+		require.Equal(t, "test.Tracing/Echo", serverSpan.Name)
+		require.Equal(t, trace.SpanKindClient, clientSpan.SpanKind)
+		require.Equal(t, "test.Tracing/Echo", clientSpan.Name)
+
+		// The trace context crossed the wire.
+		require.Equal(t, clientSpan.SpanContext.TraceID(), serverSpan.SpanContext.TraceID())
+		require.Equal(t, clientSpan.SpanContext.SpanID(), serverSpan.Parent.SpanID())
+		require.True(t, serverSpan.Parent.IsRemote())
+		require.Equal(t, serverSpan.SpanContext.SpanID(), handlerSpan.SpanID())
+
+		require.Equal(t, "test.Tracing", spanAttr(t, serverSpan, "rpc.service").AsString())
+		require.Equal(t, "Echo", spanAttr(t, serverSpan, "rpc.method").AsString())
+		require.Equal(t, int64(200), spanAttr(t, serverSpan, "http.response.status_code").AsInt64())
+		require.Positive(t, spanAttr(t, serverSpan, "http.request.body.size").AsInt64())
+		require.Positive(t, spanAttr(t, serverSpan, "http.response.body.size").AsInt64())
+
+		require.Equal(t, "test.tracing", spanAttr(t, clientSpan, "rpc.service").AsString())
+		require.Equal(t, "Echo", spanAttr(t, clientSpan, "rpc.method").AsString())
+		require.Equal(t, int64(200), spanAttr(t, clientSpan, "http.response.status_code").AsInt64())
+		require.Positive(t, spanAttr(t, clientSpan, "http.request.body.size").AsInt64())
+		require.Positive(t, spanAttr(t, clientSpan, "http.response.body.size").AsInt64())
+		require.Equal(t, codes.Unset, clientSpan.Status.Code)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		exporter.Reset()
+
+		require.Error(t, request("/test.Tracing/Fail"))
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 2)
+
+		serverSpan, clientSpan := spans[0], spans[1]
+
+		require.Equal(t, codes.Error, serverSpan.Status.Code)
+		require.Equal(t, int64(503), spanAttr(t, serverSpan, "http.response.status_code").AsInt64())
+		require.Equal(t, codes.Error, clientSpan.Status.Code)
+		require.Equal(t, int64(503), spanAttr(t, clientSpan, "http.response.status_code").AsInt64())
+	})
+	t.Run("propagator", func(t *testing.T) {
+		exporter.Reset()
+
+		// A propagator without fields sends no trace context.
+		noop, err := NewHTTPTransport(logger, &cfg, WithTracerProvider(tp), WithPropagator(propagation.NewCompositeTextMapPropagator()))
+		require.NoError(t, err)
+
+		require.NoError(t, noop.Request(
+			ctx,
+			client.RequestInfos{Service: "test.tracing", Endpoint: "/test.Tracing/Echo", Address: ep.Address()},
+			&streamMsg{Text: "hello"},
+			&streamMsg{},
+			&client.CallOptions{ContentType: codecs.MimeJSON},
+		))
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 2)
+
+		serverSpan, clientSpan := spans[0], spans[1]
+
+		require.False(t, serverSpan.Parent.IsValid())
+		require.NotEqual(t, clientSpan.SpanContext.TraceID(), serverSpan.SpanContext.TraceID())
+	})
+}
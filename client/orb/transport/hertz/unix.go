@@ -0,0 +1,104 @@
+package hertz
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/network"
+)
+
+// unixHostSuffix marks hosts which encode the path of a unix socket.
+const unixHostSuffix = ".unix"
+
+// isUnixAddress returns the socket path if the address is a unix socket,
+// either in the form "unix:///path/to/socket" or "/path/to/socket".
+func isUnixAddress(address string) (string, bool) {
+	if path, ok := strings.CutPrefix(address, "unix://"); ok {
+		return path, true
+	}
+
+	if strings.HasPrefix(address, "/") {
+		return address, true
+	}
+
+	return "", false
+}
+
+// requestHost returns the host to use in request URIs,
+// hertz requires a host, so socket paths get encoded into one.
+func requestHost(address string) string {
+	path, ok := isUnixAddress(address)
+	if !ok {
+		return address
+	}
+
+	return hex.EncodeToString([]byte(path)) + unixHostSuffix
+}
+
+// unixSocketPath decodes a host created by requestHost back into the socket path.
+func unixSocketPath(address string) (string, bool) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+
+	encoded, ok := strings.CutSuffix(host, unixHostSuffix)
+	if !ok {
+		return "", false
+	}
+
+	path, err := hex.DecodeString(encoded)
+	if err != nil {
+		return "", false
+	}
+
+	return string(path), true
+}
+
+// unixDialer dials unix sockets for hosts created by requestHost
+// and everything else with the wrapped dialer. Its errors are
+// wrapped in a dialError, so retries know the request hasn't been sent.
+type unixDialer struct {
+	network.Dialer
+}
+
+func newUnixDialer(d network.Dialer) network.Dialer {
+	return &unixDialer{Dialer: d}
+}
+
+func (d *unixDialer) DialConnection(
+	nw, address string,
+	timeout time.Duration,
+	tlsConfig *tls.Config,
+) (network.Conn, error) {
+	if path, ok := unixSocketPath(address); ok {
+		nw, address = "unix", path
+	}
+
+	conn, err := d.Dialer.DialConnection(nw, address, timeout, tlsConfig)
+	if err != nil {
+		return nil, &dialError{err: err}
+	}
+
+	return conn, nil
+}
+
+func (d *unixDialer) DialTimeout(
+	nw, address string,
+	timeout time.Duration,
+	tlsConfig *tls.Config,
+) (net.Conn, error) {
+	if path, ok := unixSocketPath(address); ok {
+		nw, address = "unix", path
+	}
+
+	conn, err := d.Dialer.DialTimeout(nw, address, timeout, tlsConfig)
+	if err != nil {
+		return nil, &dialError{err: err}
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,81 @@
+package hertz
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/log"
+	"github.com/go-orb/go-orb/server"
+	"github.com/go-orb/plugins/client/orb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-orb/plugins-experimental/server/hertz"
+)
+
+func TestUnixRequestHost(t *testing.T) {
+	for _, address := range []string{"unix:///tmp/orb.sock", "/tmp/orb.sock"} {
+		host := requestHost(address)
+		require.NotContains(t, host, "/")
+
+		path, ok := unixSocketPath(host)
+		require.True(t, ok)
+		require.Equal(t, "/tmp/orb.sock", path)
+
+		// Hertz adds the default port to hosts without one.
+		path, ok = unixSocketPath(host + ":80")
+		require.True(t, ok)
+		require.Equal(t, "/tmp/orb.sock", path)
+	}
+
+	require.Equal(t, "127.0.0.1:8080", requestHost("127.0.0.1:8080"))
+
+	_, ok := unixSocketPath("127.0.0.1:8080")
+	require.False(t, ok)
+}
+
+func TestUnixTransports(t *testing.T) {
+	tests := []struct {
+		transport    string
+		newTransport func(log.Logger, *orb.Config, ...TransportOption) (orb.TransportType, error)
+		opts         []server.Option
+	}{
+		{transport: "hertzhttp", newTransport: NewHTTPTransport, opts: []server.Option{hertz.WithInsecure()}},
+		{transport: "hertzh2c", newTransport: NewH2CTransport, opts: []server.Option{hertz.WithInsecure(), hertz.WithAllowH2C()}},
+		{transport: "hertzhttps", newTransport: NewHTTPSTransport},
+		{transport: "hertzh2", newTransport: NewH2Transport},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.transport, func(t *testing.T) {
+			// Socket paths are limited to about 100 bytes, t.TempDir can be longer.
+			dir, err := os.MkdirTemp("", "orb")
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = os.RemoveAll(dir) }) //nolint:errcheck
+
+			path := filepath.Join(dir, "hertz.sock")
+
+			ep, logger := setupTestServer(t, func(s *hertz.Server) {
+				s.Router().POST(tlsEndpoint, func(_ context.Context, ctx *app.RequestContext) {
+					ctx.Data(consts.StatusOK, codecs.MimeJSON, []byte(`{"text":"ok"}`))
+				})
+			}, append(tc.opts, hertz.WithNetwork(hertz.NetworkUnix), hertz.WithAddress(path))...)
+
+			require.Equal(t, path, ep.Address())
+
+			for _, address := range []string{path, "unix://" + path} {
+				cfg := orb.NewConfig()
+
+				// The self-signed certificate is for localhost.
+				tt, err := tc.newTransport(logger, &cfg, WithServerName("localhost"), WithInsecureSkipVerify())
+				require.NoError(t, err)
+
+				require.NoError(t, tlsRequest(t, tt, address))
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package hertz
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/util/orberrors"
+)
+
+// ErrRequestBodyTooLarge is returned when a request body exceeds the body size limit.
+var ErrRequestBodyTooLarge = errors.New("request body too large")
+
+// WithRouteMaxRequestBodyBytes overrides Config.MaxRequestBodyBytes for the route,
+// for stream routes it limits the size of each message.
+func WithRouteMaxRequestBodyBytes(size int) HandlerOption {
+	return func(o *handlerOptions) {
+		o.maxRequestBodyBytes = size
+	}
+}
+
+// bodyLimit returns the body size limit of a route with the options.
+func (s *Server) bodyLimit(o *handlerOptions) int {
+	if o != nil && o.maxRequestBodyBytes > 0 {
+		return o.maxRequestBodyBytes
+	}
+
+	return s.config.MaxRequestBodyBytes
+}
+
+// errBodyTooLarge returns the error for a request body above the limit.
+func errBodyTooLarge() error {
+	return orberrors.HTTP(consts.StatusRequestEntityTooLarge).Wrap(ErrRequestBodyTooLarge)
+}
+
+// limitedBody limits a streamed request body to n bytes, reads beyond
+// that fail with a "413 Request Entity Too Large" error.
+type limitedBody struct {
+	r        io.Reader
+	n        int
+	read     int
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, errBodyTooLarge()
+	}
+
+	// Read one byte more than allowed to notice bodies above the limit.
+	if rem := b.n - b.read + 1; len(p) > rem {
+		p = p[:rem]
+	}
+
+	n, err := b.r.Read(p)
+	b.read += n
+
+	if b.read > b.n {
+		b.exceeded = true
+
+		return n - (b.read - b.n), errBodyTooLarge()
+	}
+
+	return n, err
+}
+
+// limitBody is a hertz middleware which limits streamed request bodies to
+// Config.MaxRequestBodyBytes, it applies to all routes of the router.
+//
+// Hertz streams request bodies above that size, without the limit
+// ctx.Request.Body() would read them completely into memory.
+func (s *Server) limitBody(ctx context.Context, apCtx *app.RequestContext) {
+	req := &apCtx.Request
+	if !req.IsBodyStream() {
+		apCtx.Next(ctx)
+		return
+	}
+
+	body := &limitedBody{r: req.BodyStream(), n: s.config.MaxRequestBodyBytes}
+	req.ConstructBodyStream(req.BodyBuffer(), body)
+
+	apCtx.Next(ctx)
+
+	switch {
+	case body.exceeded:
+		discardBody(apCtx)
+	case req.BodyStream() == io.Reader(body):
+		// Hertz releases its own body stream only, it skips what hasn't been read.
+		req.ConstructBodyStream(req.BodyBuffer(), body.r)
+	}
+}
+
+// readBody reads the request body into memory and decompresses it, both the
+// body and the decompressed body may be up to maxSize bytes.
+func readBody(apCtx *app.RequestContext, maxSize int) error {
+	req := &apCtx.Request
+
+	if !req.IsBodyStream() {
+		if len(req.Body()) > maxSize {
+			return errBodyTooLarge()
+		}
+
+		return decompressRequest(apCtx, maxSize)
+	}
+
+	body, ok := req.BodyStream().(*limitedBody)
+	if !ok {
+		body = &limitedBody{r: req.BodyStream()}
+	}
+
+	body.n = maxSize
+	body.exceeded = req.Header.ContentLength() > maxSize
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		if body.exceeded {
+			discardBody(apCtx)
+			return errBodyTooLarge()
+		}
+
+		return orberrors.ErrBadRequest.Wrap(err)
+	}
+
+	req.SetBody(data)
+
+	return decompressRequest(apCtx, maxSize)
+}
+
+// rawBodyStream returns the request body stream without the limit of limitBody,
+// streams limit the size of each message instead.
+func rawBodyStream(apCtx *app.RequestContext) io.Reader {
+	if body, ok := apCtx.RequestBodyStream().(*limitedBody); ok {
+		return body.r
+	}
+
+	return apCtx.RequestBodyStream()
+}
+
+// discardBody drops the rest of a body which is too large and closes the connection,
+// hertz would read it to the end otherwise.
+func discardBody(apCtx *app.RequestContext) {
+	apCtx.Request.ConstructBodyStream(apCtx.Request.BodyBuffer(), protocol.NoBody)
+	apCtx.SetConnectionClose()
+}
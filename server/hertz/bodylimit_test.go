@@ -0,0 +1,107 @@
+package hertz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	limitEndpoint      = "/test.Limit/Echo"
+	largeLimitEndpoint = "/test.Limit/Large"
+)
+
+func TestLimitedBody(t *testing.T) {
+	b := &limitedBody{r: strings.NewReader("0123456789"), n: 10}
+
+	data, err := io.ReadAll(b)
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", string(data))
+
+	b = &limitedBody{r: strings.NewReader("0123456789"), n: 4}
+
+	data, err = io.ReadAll(b)
+	require.ErrorIs(t, err, ErrRequestBodyTooLarge)
+	require.Equal(t, "0123", string(data))
+
+	// The error sticks.
+	_, err = b.Read(make([]byte, 1))
+	requireTooLarge(t, err)
+}
+
+func TestBodyLimit(t *testing.T) {
+	srv := setupServer(t,
+		WithInsecure(),
+		WithMaxRequestBodyBytes(1024),
+		withRoutes(func(s *Server) {
+			s.Router().POST(limitEndpoint, NewGRPCHandler(s, echoCodecMsg, "test.Limit", "Echo"))
+			s.Router().POST(largeLimitEndpoint, NewGRPCHandler(s, echoCodecMsg, "test.Limit", "Large",
+				WithRouteMaxRequestBodyBytes(64*1024)))
+		}),
+	)
+
+	body := func(size int) []byte {
+		return []byte(`{"text":"` + strings.Repeat("a", size) + `"}`)
+	}
+
+	gzipped, err := compress(CompressionGzip, body(8*1024), 0)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		endpoint string
+		body     []byte
+		encoding string
+		tooLarge bool
+	}{
+		{name: "below the limit", endpoint: limitEndpoint, body: body(5)},
+		{name: "above the limit", endpoint: limitEndpoint, body: body(2048), tooLarge: true},
+		{name: "route override", endpoint: largeLimitEndpoint, body: body(32 * 1024)},
+		{name: "above the route override", endpoint: largeLimitEndpoint, body: body(128 * 1024), tooLarge: true},
+		{name: "decompressed above the limit", endpoint: limitEndpoint, body: gzipped, encoding: CompressionGzip, tooLarge: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			header := map[string]string{"Content-Type": consts.MIMEApplicationJSON}
+			if tc.encoding != "" {
+				header["Content-Encoding"] = tc.encoding
+			}
+
+			resp, data := doRequest(t, srv, http.MethodPost, tc.endpoint, tc.body, header)
+			if !tc.tooLarge {
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+				return
+			}
+
+			require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+			errBody := &errorBody{}
+			require.NoError(t, json.Unmarshal(data, errBody))
+			require.NotNil(t, errBody.Wrapped)
+			require.Equal(t, ErrRequestBodyTooLarge.Error(), errBody.Wrapped.Message)
+		})
+	}
+
+	// A chunked body without Content-Length gets limited while it's streamed.
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, serverURL(srv, limitEndpoint),
+		io.MultiReader(bytes.NewReader(body(2048)), strings.NewReader("")))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", consts.MIMEApplicationJSON)
+
+	resp, err := httpClient(srv).Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	// The connection of a rejected body gets closed, the next request uses a new one.
+	resp, _ = doRequest(t, srv, http.MethodPost, limitEndpoint, body(5), map[string]string{"Content-Type": consts.MIMEApplicationJSON})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,274 @@
+package hertz
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression algorithms, the names are the HTTP content codings.
+const (
+	CompressionGzip   = "gzip"
+	CompressionBrotli = "br"
+	CompressionZstd   = "zstd"
+
+	compressionIdentity = "identity"
+)
+
+// zstdEncoders caches a zstd encoder per level, EncodeAll is safe for concurrent use.
+var zstdEncoders sync.Map //nolint:gochecknoglobals
+
+// isCompression returns whether we support the compression algorithm.
+func isCompression(algorithm string) bool {
+	switch algorithm {
+	case CompressionGzip, CompressionBrotli, CompressionZstd:
+		return true
+	default:
+		return false
+	}
+}
+
+// compress compresses data with the algorithm, level 0 is the algorithms
+// default, levels above the algorithms maximum get capped.
+func compress(algorithm string, data []byte, level int) ([]byte, error) {
+	switch algorithm {
+	case CompressionZstd:
+		enc, err := zstdEncoder(level)
+		if err != nil {
+			return nil, err
+		}
+
+		return enc.EncodeAll(data, nil), nil
+	case CompressionGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+
+		buf := bytes.NewBuffer(nil)
+
+		w, err := gzip.NewWriterLevel(buf, min(level, gzip.BestCompression))
+		if err != nil {
+			return nil, err
+		}
+
+		return writeCompressed(buf, w, data)
+	case CompressionBrotli:
+		if level == 0 {
+			level = brotli.DefaultCompression
+		}
+
+		buf := bytes.NewBuffer(nil)
+
+		return writeCompressed(buf, brotli.NewWriterLevel(buf, min(level, brotli.BestCompression)), data)
+	default:
+		return nil, fmt.Errorf("%w: '%s'", ErrUnsupportedEncoding, algorithm)
+	}
+}
+
+func writeCompressed(buf *bytes.Buffer, w io.WriteCloser, data []byte) ([]byte, error) {
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func zstdEncoder(level int) (*zstd.Encoder, error) {
+	if enc, ok := zstdEncoders.Load(level); ok {
+		return enc.(*zstd.Encoder), nil //nolint:forcetypeassert
+	}
+
+	zlevel := zstd.SpeedDefault
+	if level > 0 {
+		zlevel = zstd.EncoderLevelFromZstd(level)
+	}
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zlevel))
+	if err != nil {
+		return nil, err
+	}
+
+	actual, _ := zstdEncoders.LoadOrStore(level, enc)
+
+	return actual.(*zstd.Encoder), nil //nolint:forcetypeassert
+}
+
+// decompress decompresses data with the algorithm, maxSize limits
+// the size of the decompressed data when it's above 0.
+func decompress(algorithm string, data []byte, maxSize int) ([]byte, error) {
+	var r io.Reader
+
+	switch algorithm {
+	case CompressionGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close() //nolint:errcheck
+
+		r = gr
+	case CompressionBrotli:
+		r = brotli.NewReader(bytes.NewReader(data))
+	case CompressionZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(data), zstdDecoderOptions(maxSize)...)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+
+		r = zr
+	default:
+		return nil, fmt.Errorf("%w: '%s'", ErrUnsupportedEncoding, algorithm)
+	}
+
+	if maxSize > 0 {
+		r = io.LimitReader(r, int64(maxSize)+1)
+	}
+
+	out, err := io.ReadAll(r)
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) ||
+		(maxSize > 0 && len(out) > maxSize) {
+		return nil, errBodyTooLarge()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// zstdDecoderOptions limits the window and the decoded size of the zstd decoder
+// to maxSize when it's above 0, else a frame may make it allocate up to the
+// maximum window size before the LimitReader steps in.
+func zstdDecoderOptions(maxSize int) []zstd.DOption {
+	opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+	if maxSize > 0 {
+		opts = append(opts,
+			zstd.WithDecoderMaxMemory(uint64(maxSize)),                          //nolint:gosec
+			zstd.WithDecoderMaxWindow(max(uint64(maxSize), zstd.MinWindowSize)), //nolint:gosec
+		)
+	}
+
+	return opts
+}
+
+// negotiateEncoding returns the first of the algorithms with the highest
+// quality in the Accept-Encoding header, it's empty if none is acceptable.
+func negotiateEncoding(acceptEncoding string, algorithms []string) string {
+	qualities := map[string]float64{}
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+
+		q := 1.0
+
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+
+			q = f
+		}
+
+		qualities[coding] = q
+	}
+
+	best, bestQ := "", 0.0
+
+	for _, algorithm := range algorithms {
+		q, ok := qualities[algorithm]
+		if !ok {
+			q = qualities["*"]
+		}
+
+		if q > bestQ {
+			best, bestQ = algorithm, q
+		}
+	}
+
+	return best
+}
+
+// compressBody compresses the response body with the algorithm negotiated by the Accept-Encoding header.
+func (s *Server) compressBody(ctx *app.RequestContext, data []byte) ([]byte, error) {
+	if len(s.config.CompressionAlgorithms) == 0 {
+		return data, nil
+	}
+
+	ctx.Response.Header.Add("Vary", consts.HeaderAcceptEncoding)
+
+	if len(data) < s.config.CompressionMinSize {
+		return data, nil
+	}
+
+	encoding := negotiateEncoding(
+		string(ctx.Request.Header.Peek(consts.HeaderAcceptEncoding)),
+		s.config.CompressionAlgorithms,
+	)
+	if encoding == "" {
+		return data, nil
+	}
+
+	out, err := compress(encoding, data, s.config.CompressionLevel)
+	if err != nil {
+		return nil, orberrors.ErrInternalServerError.Wrap(err)
+	}
+
+	// Not worth it.
+	if len(out) >= len(data) {
+		return data, nil
+	}
+
+	ctx.Response.Header.Set(consts.HeaderContentEncoding, encoding)
+
+	return out, nil
+}
+
+// decompressRequest replaces a compressed request body with the decompressed one,
+// which may be up to maxSize bytes.
+func decompressRequest(ctx *app.RequestContext, maxSize int) error {
+	encoding := strings.ToLower(string(ctx.Request.Header.Peek(consts.HeaderContentEncoding)))
+	if encoding == "" || encoding == compressionIdentity {
+		return nil
+	}
+
+	body, err := decompress(encoding, ctx.Request.Body(), maxSize)
+	if err != nil {
+		if orbe, ok := orberrors.As(err); ok {
+			return orbe
+		}
+
+		if errors.Is(err, ErrUnsupportedEncoding) {
+			return orberrors.HTTP(consts.StatusUnsupportedMediaType).Wrap(err)
+		}
+
+		return orberrors.ErrBadRequest.Wrap(err)
+	}
+
+	ctx.Request.SetBody(body)
+	ctx.Request.Header.Del(consts.HeaderContentEncoding)
+
+	return nil
+}
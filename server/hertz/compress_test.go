@@ -0,0 +1,165 @@
+package hertz
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+var compressionAlgorithms = []string{CompressionGzip, CompressionBrotli, CompressionZstd} //nolint:gochecknoglobals
+
+// requireTooLarge requires err to be the 413 error of the body limit.
+func requireTooLarge(t *testing.T, err error) {
+	t.Helper()
+
+	orbe, ok := orberrors.As(err)
+	require.True(t, ok)
+	require.Equal(t, consts.StatusRequestEntityTooLarge, orbe.Code)
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	data := []byte(strings.Repeat("go-orb hertz compression ", 100))
+
+	for _, algorithm := range compressionAlgorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			compressed, err := compress(algorithm, data, 0)
+			require.NoError(t, err)
+			require.Less(t, len(compressed), len(data))
+
+			out, err := decompress(algorithm, compressed, 0)
+			require.NoError(t, err)
+			require.Equal(t, data, out)
+
+			out, err = decompress(algorithm, compressed, len(data))
+			require.NoError(t, err)
+			require.Equal(t, data, out)
+
+			_, err = decompress(algorithm, compressed, 10)
+			requireTooLarge(t, err)
+		})
+	}
+}
+
+func TestDecompressZstdLimits(t *testing.T) {
+	// A frame which expands to 16 MiB.
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+
+	bomb := enc.EncodeAll(make([]byte, 16*1024*1024), nil)
+	require.NoError(t, enc.Close())
+
+	_, err = decompress(CompressionZstd, bomb, DefaultMaxRequestBodyBytes)
+	requireTooLarge(t, err)
+
+	// A small body in a frame which asks for a large window, Flush
+	// before Close prevents a single segment frame.
+	buf := bytes.NewBuffer(nil)
+
+	w, err := zstd.NewWriter(buf, zstd.WithWindowSize(8*1024*1024))
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte(strings.Repeat("window ", 1024)))
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+	require.NoError(t, w.Close())
+
+	_, err = decompress(CompressionZstd, buf.Bytes(), 64*1024)
+	requireTooLarge(t, err)
+
+	out, err := decompress(CompressionZstd, buf.Bytes(), 16*1024*1024)
+	require.NoError(t, err)
+	require.Len(t, out, 7*1024)
+}
+
+func TestCompressUnsupported(t *testing.T) {
+	_, err := compress("lzma", []byte("data"), 0)
+	require.ErrorIs(t, err, ErrUnsupportedEncoding)
+
+	_, err = decompress("lzma", []byte("data"), 0)
+	require.ErrorIs(t, err, ErrUnsupportedEncoding)
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: ""},
+		{header: "gzip", want: CompressionGzip},
+		{header: "gzip;q=0.5, zstd", want: CompressionZstd},
+		{header: "gzip, br, zstd", want: CompressionGzip},
+		{header: "*", want: CompressionGzip},
+		{header: "*, gzip;q=0", want: CompressionBrotli},
+		{header: "identity", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			require.Equal(t, tt.want, negotiateEncoding(tt.header, compressionAlgorithms))
+		})
+	}
+}
+
+func TestCompressResponse(t *testing.T) {
+	body := []byte(`{"text":"` + strings.Repeat("compress me ", 200) + `"}`)
+
+	for _, algorithm := range compressionAlgorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			srv := setupServer(t, WithInsecure(), WithCompression(algorithm), WithCompressionMinSize(0), withCodecEcho())
+
+			resp, data := doRequest(t, srv, http.MethodPost, codecEndpoint, body, map[string]string{
+				"Content-Type":    consts.MIMEApplicationJSON,
+				"Accept-Encoding": algorithm,
+			})
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, algorithm, resp.Header.Get("Content-Encoding"))
+			require.Equal(t, consts.HeaderAcceptEncoding, resp.Header.Get("Vary"))
+
+			out, err := decompress(algorithm, data, 0)
+			require.NoError(t, err)
+			require.JSONEq(t, string(body), string(out))
+		})
+	}
+}
+
+func TestDecompressRequest(t *testing.T) {
+	text := strings.Repeat("compress me ", 200)
+
+	srv := setupServer(t, WithInsecure(), WithMaxRequestBodyBytes(16*1024), withCodecEcho())
+
+	for _, algorithm := range compressionAlgorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			compressed, err := compress(algorithm, []byte(`{"text":"`+text+`"}`), 0)
+			require.NoError(t, err)
+
+			resp, data := doRequest(t, srv, http.MethodPost, codecEndpoint, compressed, map[string]string{
+				"Content-Type":     consts.MIMEApplicationJSON,
+				"Content-Encoding": algorithm,
+			})
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.JSONEq(t, `{"text":"`+text+`"}`, string(data))
+
+			// The decompressed body exceeds the limit.
+			bomb, err := compress(algorithm, []byte(`{"text":"`+strings.Repeat("a", 1024*1024)+`"}`), 0)
+			require.NoError(t, err)
+
+			resp, _ = doRequest(t, srv, http.MethodPost, codecEndpoint, bomb, map[string]string{
+				"Content-Type":     consts.MIMEApplicationJSON,
+				"Content-Encoding": algorithm,
+			})
+			require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+		})
+	}
+
+	resp, _ := doRequest(t, srv, http.MethodPost, codecEndpoint, []byte(`{}`), map[string]string{
+		"Content-Type":     consts.MIMEApplicationJSON,
+		"Content-Encoding": "lzma",
+	})
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+}
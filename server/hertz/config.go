@@ -3,11 +3,17 @@ package hertz
 import (
 	"crypto/tls"
 	"errors"
+	"math"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-orb/go-orb/log"
 	"github.com/go-orb/go-orb/server"
 	mtls "github.com/go-orb/go-orb/util/tls"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -17,6 +23,9 @@ const (
 	// DefaultAddress to use for new Hertz servers.
 	DefaultAddress = ":0"
 
+	// DefaultSocketMode are the file permissions of unix sockets.
+	DefaultSocketMode os.FileMode = 0o660
+
 	// DefaultInsecure will create an HTTP server without TLS, for insecure connections.
 	// Note: as a result you can only make insecure HTTP requests, and no HTTP2
 	// unless you set WithH2C.
@@ -55,8 +64,32 @@ const (
 	// DefaultMaxHeaderBytes is the maximum size to parse from a client's
 	// HTTP request headers.
 	DefaultMaxHeaderBytes = 1024 * 64
+
+	// DefaultMaxRequestBodyBytes is the maximum size of a request body.
+	DefaultMaxRequestBodyBytes = 4 * 1024 * 1024
+
+	// DefaultCompressionMinSize is the minimum size of a response body to get compressed.
+	DefaultCompressionMinSize = 1024
+
+	// DefaultOpenAPIPath is the path of the OpenAPI document.
+	DefaultOpenAPIPath = "/openapi.json"
+
+	// DefaultSwaggerUIPath is the path of the Swagger UI page.
+	DefaultSwaggerUIPath = "/swagger"
+
+	// DefaultHealthPath is the path of the liveness probe.
+	DefaultHealthPath = "/healthz"
+
+	// DefaultReadyPath is the path of the readiness probe.
+	DefaultReadyPath = "/readyz"
+
+	// DefaultMetricsPath is the path of the metrics route.
+	DefaultMetricsPath = "/metrics"
 )
 
+// DefaultCompressionAlgorithms are the response compression algorithms in order of preference.
+var DefaultCompressionAlgorithms = []string{CompressionZstd, CompressionBrotli, CompressionGzip} //nolint:gochecknoglobals
+
 // Errors.
 var (
 	ErrNoRouter         = errors.New("no router plugin name set in config")
@@ -73,10 +106,17 @@ type Config struct {
 	// Supported values:
 	//
 	// - "tcp"
+	// - "tcp4"
+	// - "tcp6"
+	// - "unix", Address must be the path of the socket.
 	//
 	// Defaults to "tcp".
 	Network string `json:"network" yaml:"network"`
 
+	// SocketMode are the file permissions of the socket when Network is "unix".
+	// Defaults to 0660.
+	SocketMode os.FileMode `json:"socketMode" yaml:"socketMode"`
+
 	// Address to listen on.
 	// TODO(davincible): implement this, and the address method.
 	// If no IP is provided, an interface will be selected automatically. Private
@@ -96,6 +136,7 @@ type Config struct {
 	Insecure bool `json:"insecure" yaml:"insecure"`
 
 	// TLS config, if none is provided a self-signed certificates will be generated.
+	// TLS is used unless Insecure or H2C is set, HTTP/2 gets negotiated with ALPN.
 	//
 	// You can load a tls config from yaml/json with the following options:
 	//
@@ -117,6 +158,12 @@ type Config struct {
 	// HTTP2 dicates whether to also allow HTTP/2 connections. Defaults to true.
 	HTTP2 bool `json:"http2" yaml:"http2"`
 
+	// ClientTransport is the client transport advertised in the registry, it has
+	// to be one the entrypoint serves. Defaults to "hertzh2c" with H2C,
+	// "hertzhttp" when Insecure and "hertzhttps" else. Set it to "hertzh2"
+	// to let clients stream over TLS.
+	ClientTransport string `json:"clientTransport" yaml:"clientTransport"`
+
 	// MaxConcurrentStreams for HTTP2.
 	MaxConcurrentStreams int `json:"maxConcurrentStreams" yaml:"maxConcurrentStreams"`
 
@@ -124,6 +171,13 @@ type Config struct {
 	// HTTP request headers.
 	MaxHeaderBytes int `json:"maxHeaderBytes" yaml:"maxHeaderBytes"`
 
+	// MaxRequestBodyBytes is the maximum size of a request body, larger bodies
+	// get rejected with "413 Request Entity Too Large". It applies to the
+	// compressed and the decompressed body, for streams to each message.
+	//
+	// Routes can override it with WithRouteMaxRequestBodyBytes. Defaults to 4 MiB.
+	MaxRequestBodyBytes int `json:"maxRequestBodyBytes" yaml:"maxRequestBodyBytes"`
+
 	// ReadTimeout is the maximum duration for reading the entire
 	// request, including the body. A zero or negative value means
 	// there will be no timeout.
@@ -142,12 +196,82 @@ type Config struct {
 	// zero, there is no timeout.
 	IdleTimeout time.Duration `json:"idleTimeout" yaml:"idleTimeout"`
 
-	// StopTimeout is the timeout for ServerHertz.Stop().
+	// CompressionAlgorithms are the algorithms to compress responses with,
+	// in order of preference. The client chooses with the Accept-Encoding header.
+	//
+	// Supported values are "zstd", "br" and "gzip", an empty list disables
+	// response compression. Compressed requests get always accepted.
+	CompressionAlgorithms []string `json:"compressionAlgorithms" yaml:"compressionAlgorithms"`
+
+	// CompressionMinSize is the minimum size of a response body to get compressed.
+	CompressionMinSize int `json:"compressionMinSize" yaml:"compressionMinSize"`
+
+	// CompressionLevel is the compression level for all algorithms, 0 uses the
+	// default of each algorithm. The levels are gzip 1-9, br 0-11 and zstd 1-22,
+	// higher values get capped to the algorithms maximum.
+	CompressionLevel int `json:"compressionLevel" yaml:"compressionLevel"`
+
+	// OpenAPI serves an OpenAPI 3 document of the registered routes at OpenAPIPath.
+	//
+	// Routes registered with NewGRPCHandler, NewStreamHandler or the service
+	// registrars get described with their messages, other routes only by their path.
+	OpenAPI bool `json:"openAPI" yaml:"openAPI"`
+
+	// OpenAPIPath is the path of the OpenAPI document, defaults to "/openapi.json".
+	OpenAPIPath string `json:"openAPIPath" yaml:"openAPIPath"`
+
+	// SwaggerUI serves a Swagger UI page for the OpenAPI document at SwaggerUIPath,
+	// it requires OpenAPI. The page loads Swagger UI from unpkg.com.
+	SwaggerUI bool `json:"swaggerUI" yaml:"swaggerUI"`
+
+	// SwaggerUIPath is the path of the Swagger UI page, defaults to "/swagger".
+	SwaggerUIPath string `json:"swaggerUIPath" yaml:"swaggerUIPath"`
+
+	// Health serves a liveness probe at HealthPath, a readiness probe at ReadyPath
+	// and the grpc.health.v1.Health/Check RPC.
+	//
+	// The server is alive while it serves, it's ready once it has been registered
+	// until Stop gets called and all health checks pass, see Server.AddHealthCheck.
+	Health bool `json:"health" yaml:"health"`
+
+	// HealthPath is the path of the liveness probe, defaults to "/healthz".
+	HealthPath string `json:"healthPath" yaml:"healthPath"`
+
+	// ReadyPath is the path of the readiness probe, defaults to "/readyz".
+	ReadyPath string `json:"readyPath" yaml:"readyPath"`
+
+	// ReadyDrainDelay is how long Stop keeps serving after the readiness probe
+	// reports not ready, before it deregisters and stops accepting connections.
+	// Load balancers polling the probe get that long to take the server out of
+	// rotation, it defaults to no delay.
+	ReadyDrainDelay time.Duration `json:"readyDrainDelay" yaml:"readyDrainDelay"`
+
+	// Metrics serves the metrics of the entrypoint in the Prometheus exposition
+	// format at MetricsPath. They get collected either way, see Server.Metrics.
+	Metrics bool `json:"metrics" yaml:"metrics"`
+
+	// MetricsPath is the path of the metrics route, defaults to "/metrics".
+	MetricsPath string `json:"metricsPath" yaml:"metricsPath"`
+
+	// StopTimeout is the timeout for ServerHertz.Stop(), requests in flight
+	// get this long to finish before they get aborted.
 	StopTimeout time.Duration `json:"stopTimeout" yaml:"stopTimeout"`
 
 	// Logger allows you to dynamically change the log level and plugin for a
 	// specific entrypoint.
 	Logger log.Config `json:"logger" yaml:"logger"`
+
+	// TracerProvider creates the spans of NewGRPCHandler, it defaults
+	// to the global provider of go.opentelemetry.io/otel.
+	TracerProvider trace.TracerProvider `json:"-" yaml:"-"`
+
+	// Propagator extracts the trace context from the request headers,
+	// it defaults to W3C Trace Context (traceparent and tracestate).
+	Propagator propagation.TextMapPropagator `json:"-" yaml:"-"`
+
+	// OnServeError gets called when the server stops serving while it's not being stopped.
+	// The entrypoint has been deregistered already at that point.
+	OnServeError func(err error) `json:"-" yaml:"-"`
 }
 
 // NewConfig will create a new default config for the entrypoint.
@@ -158,16 +282,29 @@ func NewConfig(options ...server.Option) *Config {
 			Enabled: true,
 		},
 		Network:              DefaultNetwork,
+		SocketMode:           DefaultSocketMode,
 		Address:              DefaultAddress,
 		Insecure:             DefaultInsecure,
 		MaxConcurrentStreams: DefaultMaxConcurrentStreams,
 		MaxHeaderBytes:       DefaultMaxHeaderBytes,
+		MaxRequestBodyBytes:  DefaultMaxRequestBodyBytes,
 		H2C:                  DefaultAllowH2C,
 		HTTP2:                DefaultHTTP2,
 		ReadTimeout:          DefaultReadTimeout,
 		WriteTimeout:         DefaultWriteTimeout,
 		IdleTimeout:          DefaultIdleTimeout,
 		StopTimeout:          DefaultStopTimeout,
+
+		CompressionAlgorithms: slices.Clone(DefaultCompressionAlgorithms),
+		CompressionMinSize:    DefaultCompressionMinSize,
+
+		OpenAPIPath:   DefaultOpenAPIPath,
+		SwaggerUIPath: DefaultSwaggerUIPath,
+
+		HealthPath: DefaultHealthPath,
+		ReadyPath:  DefaultReadyPath,
+
+		MetricsPath: DefaultMetricsPath,
 	}
 
 	for _, option := range options {
@@ -177,6 +314,69 @@ func NewConfig(options ...server.Option) *Config {
 	return cfg
 }
 
+// validate checks the config for nonsensical values and combinations.
+func (c *Config) validate() error {
+	switch {
+	case !slices.Contains([]string{"tcp", "tcp4", "tcp6", NetworkUnix}, c.Network):
+		return &ConfigError{Field: "network", Reason: "must be one of tcp, tcp4, tcp6 or unix"}
+	case c.Network == NetworkUnix && (c.Address == "" || c.Address == DefaultAddress):
+		return &ConfigError{Field: "address", Reason: "must be the path of the socket for the unix network"}
+	case c.MaxConcurrentStreams < 0 || int64(c.MaxConcurrentStreams) > math.MaxUint32:
+		return &ConfigError{Field: "maxConcurrentStreams", Reason: "must be between 0 and 2^32-1"}
+	case c.MaxHeaderBytes < 0:
+		return &ConfigError{Field: "maxHeaderBytes", Reason: "must not be negative"}
+	case c.MaxRequestBodyBytes <= 0:
+		return &ConfigError{Field: "maxRequestBodyBytes", Reason: "must be greater than 0"}
+	case c.StopTimeout < 0:
+		return &ConfigError{Field: "stopTimeout", Reason: "must not be negative"}
+	case c.ReadyDrainDelay < 0:
+		return &ConfigError{Field: "readyDrainDelay", Reason: "must not be negative"}
+	case c.H2C && !c.HTTP2:
+		return &ConfigError{Field: "h2c", Reason: "h2c requires http2 to be enabled"}
+	case slices.ContainsFunc(c.CompressionAlgorithms, func(a string) bool { return !isCompression(a) }):
+		return &ConfigError{Field: "compressionAlgorithms", Reason: "must only contain zstd, br or gzip"}
+	case c.CompressionMinSize < 0:
+		return &ConfigError{Field: "compressionMinSize", Reason: "must not be negative"}
+	case c.CompressionLevel < 0:
+		return &ConfigError{Field: "compressionLevel", Reason: "must not be negative"}
+	case c.OpenAPI && !strings.HasPrefix(c.OpenAPIPath, "/"):
+		return &ConfigError{Field: "openAPIPath", Reason: "must start with '/'"}
+	case c.SwaggerUI && !c.OpenAPI:
+		return &ConfigError{Field: "swaggerUI", Reason: "swagger UI requires openAPI to be enabled"}
+	case c.SwaggerUI && (!strings.HasPrefix(c.SwaggerUIPath, "/") || c.SwaggerUIPath == c.OpenAPIPath):
+		return &ConfigError{Field: "swaggerUIPath", Reason: "must start with '/' and differ from openAPIPath"}
+	case c.Health && !strings.HasPrefix(c.HealthPath, "/"):
+		return &ConfigError{Field: "healthPath", Reason: "must start with '/'"}
+	case c.Health && (!strings.HasPrefix(c.ReadyPath, "/") || c.ReadyPath == c.HealthPath):
+		return &ConfigError{Field: "readyPath", Reason: "must start with '/' and differ from healthPath"}
+	case c.Metrics && !strings.HasPrefix(c.MetricsPath, "/"):
+		return &ConfigError{Field: "metricsPath", Reason: "must start with '/'"}
+	case c.Insecure && c.TLS != nil:
+		return &ConfigError{Field: "tls", Reason: "a TLS config has been given for an insecure entrypoint"}
+	case c.ClientTransport != "" && !slices.Contains(c.clientTransports(), c.ClientTransport):
+		return &ConfigError{
+			Field:  "clientTransport",
+			Reason: "must be one of the served transports " + strings.Join(c.clientTransports(), ", "),
+		}
+	}
+
+	return nil
+}
+
+// clientTransports returns the client transports which can talk to the entrypoint.
+func (c *Config) clientTransports() []string {
+	switch {
+	case c.H2C:
+		return []string{"hertzh2c", "hertzhttp"}
+	case c.Insecure:
+		return []string{"hertzhttp"}
+	case c.HTTP2:
+		return []string{"hertzhttps", "hertzh2"}
+	default:
+		return []string{"hertzhttps"}
+	}
+}
+
 // WithAddress specifies the address to listen on.
 // If you want to listen on all interfaces use the format ":8080"
 // If you want to listen on a specific interface/address use the full IP.
@@ -189,6 +389,27 @@ func WithAddress(addr string) server.Option {
 	}
 }
 
+// WithNetwork sets the network to listen on, one of "tcp", "tcp4", "tcp6" or "unix".
+// For "unix" the address must be the path of the socket.
+func WithNetwork(network string) server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.Network = network
+		}
+	}
+}
+
+// WithSocketMode sets the file permissions of the unix socket.
+func WithSocketMode(mode os.FileMode) server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.SocketMode = mode
+		}
+	}
+}
+
 // WithTLS sets a tls config.
 func WithTLS(config *tls.Config) server.Option {
 	return func(c server.EntrypointConfigType) {
@@ -246,6 +467,26 @@ func WithMaxConcurrentStreams(value int) server.Option {
 	}
 }
 
+// WithMaxHeaderBytes sets the maximum size to parse from a client's HTTP request headers.
+func WithMaxHeaderBytes(value int) server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.MaxHeaderBytes = value
+		}
+	}
+}
+
+// WithMaxRequestBodyBytes sets the maximum size of a request body.
+func WithMaxRequestBodyBytes(value int) server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.MaxRequestBodyBytes = value
+		}
+	}
+}
+
 // WithReadTimeout sets the maximum duration for reading the entire request,
 // including the body. A zero or negative value means there will be no timeout.
 func WithReadTimeout(timeout time.Duration) server.Option {
@@ -282,6 +523,100 @@ func WithIdleTimeout(timeout time.Duration) server.Option {
 	}
 }
 
+// WithStopTimeout sets the timeout for ServerHertz.Stop().
+func WithStopTimeout(timeout time.Duration) server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.StopTimeout = timeout
+		}
+	}
+}
+
+// WithCompression sets the response compression algorithms in order of preference,
+// no algorithms disable response compression.
+func WithCompression(algorithms ...string) server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.CompressionAlgorithms = algorithms
+		}
+	}
+}
+
+// WithCompressionMinSize sets the minimum size of a response body to get compressed.
+func WithCompressionMinSize(size int) server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.CompressionMinSize = size
+		}
+	}
+}
+
+// WithCompressionLevel sets the compression level, 0 uses the default of each algorithm.
+func WithCompressionLevel(level int) server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.CompressionLevel = level
+		}
+	}
+}
+
+// WithOpenAPI serves the OpenAPI document of the registered routes at DefaultOpenAPIPath.
+func WithOpenAPI() server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.OpenAPI = true
+		}
+	}
+}
+
+// WithSwaggerUI serves the OpenAPI document and a Swagger UI page for it at DefaultSwaggerUIPath.
+func WithSwaggerUI() server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.OpenAPI = true
+			cfg.SwaggerUI = true
+		}
+	}
+}
+
+// WithHealth serves the liveness and readiness probes at DefaultHealthPath and
+// DefaultReadyPath, as well as the grpc.health.v1.Health/Check RPC.
+func WithHealth() server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.Health = true
+		}
+	}
+}
+
+// WithReadyDrainDelay sets how long Stop waits after the readiness probe reports
+// not ready before it deregisters and stops accepting connections.
+func WithReadyDrainDelay(delay time.Duration) server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.ReadyDrainDelay = delay
+		}
+	}
+}
+
+// WithMetrics serves the metrics of the entrypoint at DefaultMetricsPath.
+func WithMetrics() server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.Metrics = true
+		}
+	}
+}
+
 // WithHandlers adds custom handlers.
 func WithHandlers(h ...server.RegistrationFunc) server.Option {
 	return func(c server.EntrypointConfigType) {
@@ -292,6 +627,46 @@ func WithHandlers(h ...server.RegistrationFunc) server.Option {
 	}
 }
 
+// WithTracerProvider sets the provider which creates the spans of NewGRPCHandler.
+func WithTracerProvider(tp trace.TracerProvider) server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.TracerProvider = tp
+		}
+	}
+}
+
+// WithPropagator sets the propagator which extracts the trace context from the request headers.
+func WithPropagator(p propagation.TextMapPropagator) server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.Propagator = p
+		}
+	}
+}
+
+// WithClientTransport sets the client transport advertised in the registry, see Config.ClientTransport.
+func WithClientTransport(name string) server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.ClientTransport = name
+		}
+	}
+}
+
+// WithOnServeError sets a callback which gets called when the server stops serving unexpectedly.
+func WithOnServeError(fn func(err error)) server.Option {
+	return func(c server.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			cfg.OnServeError = fn
+		}
+	}
+}
+
 // WithLogLevel changes the log level from the inherited logger.
 func WithLogLevel(level string) server.Option {
 	return func(c server.EntrypointConfigType) {
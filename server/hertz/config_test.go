@@ -0,0 +1,82 @@
+package hertz
+
+import (
+	"crypto/tls"
+	"errors"
+	"testing"
+
+	orbserver "github.com/go-orb/go-orb/server"
+	"github.com/stretchr/testify/require"
+)
+
+// withConfig changes the config directly, for fields without an option.
+func withConfig(fn func(c *Config)) orbserver.Option {
+	return func(c orbserver.EntrypointConfigType) {
+		cfg, ok := c.(*Config)
+		if ok {
+			fn(cfg)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []orbserver.Option
+		field string
+	}{
+		{name: "defaults"},
+		{name: "insecure", opts: []orbserver.Option{WithInsecure()}},
+		{name: "h2c", opts: []orbserver.Option{WithInsecure(), WithAllowH2C()}},
+		{name: "unix", opts: []orbserver.Option{WithNetwork(NetworkUnix), WithAddress("/tmp/hertz.sock")}},
+		{name: "network", opts: []orbserver.Option{WithNetwork("udp")}, field: "network"},
+		{name: "unix without path", opts: []orbserver.Option{WithNetwork(NetworkUnix)}, field: "address"},
+		{name: "h2c without http2", opts: []orbserver.Option{WithAllowH2C(), WithDisableHTTP2()}, field: "h2c"},
+		{name: "insecure with tls", opts: []orbserver.Option{WithInsecure(), WithTLS(&tls.Config{})}, field: "tls"}, //nolint:gosec
+		{name: "zero body limit", opts: []orbserver.Option{WithMaxRequestBodyBytes(0)}, field: "maxRequestBodyBytes"},
+		{name: "negative body limit", opts: []orbserver.Option{WithMaxRequestBodyBytes(-1)}, field: "maxRequestBodyBytes"},
+		{name: "negative header limit", opts: []orbserver.Option{WithMaxHeaderBytes(-1)}, field: "maxHeaderBytes"},
+		{name: "negative stop timeout", opts: []orbserver.Option{WithStopTimeout(-1)}, field: "stopTimeout"},
+		{name: "negative ready drain delay", opts: []orbserver.Option{WithReadyDrainDelay(-1)}, field: "readyDrainDelay"},
+		{name: "compression", opts: []orbserver.Option{WithCompression("lz4")}, field: "compressionAlgorithms"},
+		{name: "openapi path", opts: []orbserver.Option{
+			WithOpenAPI(), withConfig(func(c *Config) { c.OpenAPIPath = "openapi.json" }),
+		}, field: "openAPIPath"},
+		{name: "swagger without openapi", opts: []orbserver.Option{
+			withConfig(func(c *Config) { c.SwaggerUI = true }),
+		}, field: "swaggerUI"},
+		{name: "swagger path", opts: []orbserver.Option{
+			WithOpenAPI(), WithSwaggerUI(), withConfig(func(c *Config) { c.SwaggerUIPath = DefaultOpenAPIPath }),
+		}, field: "swaggerUIPath"},
+		{name: "health path", opts: []orbserver.Option{
+			WithHealth(), withConfig(func(c *Config) { c.HealthPath = "healthz" }),
+		}, field: "healthPath"},
+		{name: "ready path", opts: []orbserver.Option{
+			WithHealth(), withConfig(func(c *Config) { c.ReadyPath = DefaultHealthPath }),
+		}, field: "readyPath"},
+		{name: "client transport h2", opts: []orbserver.Option{WithClientTransport("hertzh2")}},
+		{name: "client transport h2 insecure", opts: []orbserver.Option{
+			WithInsecure(), WithClientTransport("hertzh2"),
+		}, field: "clientTransport"},
+		{name: "client transport unknown", opts: []orbserver.Option{WithClientTransport("grpc")}, field: "clientTransport"},
+		{name: "metrics path", opts: []orbserver.Option{
+			WithMetrics(), withConfig(func(c *Config) { c.MetricsPath = "" }),
+		}, field: "metricsPath"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewConfig(tt.opts...).validate()
+			if tt.field == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrInvalidConfig)
+
+			var cfgErr *ConfigError
+			require.True(t, errors.As(err, &cfgErr))
+			require.Equal(t, tt.field, cfgErr.Field)
+		})
+	}
+}
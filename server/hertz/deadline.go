@@ -0,0 +1,126 @@
+package hertz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/go-orb/go-orb/util/orberrors"
+)
+
+// TimeoutHeader carries the time the client waits for the response,
+// the format is the one of grpc-timeout: up to 8 digits followed by the
+// unit, "H" hours, "M" minutes, "S" seconds, "m" milliseconds,
+// "u" microseconds or "n" nanoseconds.
+const TimeoutHeader = "Orb-Timeout"
+
+// maxTimeoutValue is the maximum value of the TimeoutHeader, 8 digits.
+const maxTimeoutValue = 99_999_999
+
+// ErrInvalidTimeout is returned for a malformed TimeoutHeader.
+var ErrInvalidTimeout = errors.New("invalid timeout header")
+
+//nolint:gochecknoglobals
+var timeoutUnits = []struct {
+	unit byte
+	d    time.Duration
+}{
+	{'n', time.Nanosecond},
+	{'u', time.Microsecond},
+	{'m', time.Millisecond},
+	{'S', time.Second},
+	{'M', time.Minute},
+	{'H', time.Hour},
+}
+
+// EncodeTimeout formats a timeout for the TimeoutHeader,
+// it uses the most precise unit and rounds up.
+func EncodeTimeout(d time.Duration) string {
+	if d <= 0 {
+		return "0n"
+	}
+
+	for _, u := range timeoutUnits {
+		v := (d + u.d - 1) / u.d
+		if v <= maxTimeoutValue {
+			return strconv.FormatInt(int64(v), 10) + string(u.unit)
+		}
+	}
+
+	return strconv.Itoa(maxTimeoutValue) + "H"
+}
+
+// DecodeTimeout parses the value of the TimeoutHeader.
+func DecodeTimeout(s string) (time.Duration, error) {
+	if len(s) < 2 || len(s) > 9 {
+		return 0, fmt.Errorf("%w: '%s'", ErrInvalidTimeout, s)
+	}
+
+	v, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("%w: '%s'", ErrInvalidTimeout, s)
+	}
+
+	for _, u := range timeoutUnits {
+		if u.unit != s[len(s)-1] {
+			continue
+		}
+
+		if v > math.MaxInt64/int64(u.d) {
+			return time.Duration(math.MaxInt64), nil
+		}
+
+		return time.Duration(v) * u.d, nil
+	}
+
+	return 0, fmt.Errorf("%w: '%s'", ErrInvalidTimeout, s)
+}
+
+// disconnectSenser is implemented by HTTP/1 connections of our transport.
+type disconnectSenser interface {
+	senseDisconnect(cancel context.CancelFunc) func()
+}
+
+// requestContext derives the handler context, it gets the deadline from the
+// TimeoutHeader and gets canceled when the client closes the connection.
+//
+// The returned cancel function must be called before hertz writes the response.
+func requestContext(ctx context.Context, apCtx *app.RequestContext) (context.Context, context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	if v := string(apCtx.Request.Header.Peek(TimeoutHeader)); v != "" {
+		timeout, err := DecodeTimeout(v)
+		if err != nil {
+			cancel()
+
+			return nil, nil, orberrors.ErrBadRequest.Wrap(err)
+		}
+
+		var cancelTimeout context.CancelFunc
+
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		cancelCtx := cancel
+
+		cancel = func() {
+			cancelTimeout()
+			cancelCtx()
+		}
+	}
+
+	// A streamed body is still read from the connection by the handler.
+	if c, ok := apCtx.GetConn().(disconnectSenser); ok && !apCtx.Request.IsBodyStream() {
+		stop := c.senseDisconnect(cancel)
+		cancelCtx := cancel
+
+		cancel = func() {
+			stop()
+			cancelCtx()
+		}
+	}
+
+	return ctx, cancel, nil
+}
@@ -0,0 +1,36 @@
+package hertz
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/stretchr/testify/require"
+)
+
+const sleepEndpoint = "/test.Deadline/Sleep"
+
+func TestHandlerOutlivesReadTimeout(t *testing.T) {
+	sleep := func(ctx context.Context, req *codecMsg) (*codecMsg, error) {
+		select {
+		case <-time.After(500 * time.Millisecond):
+			return req, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	srv := setupServer(t, WithInsecure(), WithReadTimeout(100*time.Millisecond), withRoutes(func(s *Server) {
+		s.Router().POST(sleepEndpoint, NewGRPCHandler(s, sleep, "test.Deadline", "Sleep"))
+	}))
+
+	// The handler runs longer than the read timeout, but within the deadline of the client.
+	resp, body := doRequest(t, srv, http.MethodPost, sleepEndpoint, []byte(`{"text":"slow"}`), map[string]string{
+		"Content-Type": consts.MIMEApplicationJSON,
+		TimeoutHeader:  "5S",
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	require.JSONEq(t, `{"text":"slow"}`, string(body))
+}
@@ -0,0 +1,137 @@
+package hertz
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/go-orb/go-orb/util/orberrors"
+)
+
+// drainer counts the requests in flight, so Stop can wait for them.
+type drainer struct {
+	mu  sync.Mutex
+	gen *drainGeneration
+}
+
+// drainGeneration counts the requests of one start of the server, requests
+// aborted by a previous Stop keep ending on their own generation.
+type drainGeneration struct {
+	mu       sync.Mutex
+	inFlight int
+	draining bool
+
+	// idle gets closed once draining and no request is in flight.
+	idle chan struct{}
+}
+
+// reset starts a new generation for a new start of the server.
+func (d *drainer) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.gen = &drainGeneration{idle: make(chan struct{})}
+}
+
+// current returns the generation of the running server.
+func (d *drainer) current() *drainGeneration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.gen
+}
+
+// begin adds a request, it returns nil when the server drains.
+// The request has to be removed with end of the returned generation.
+func (d *drainer) begin() *drainGeneration {
+	g := d.current()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.draining {
+		return nil
+	}
+
+	g.inFlight++
+
+	return g
+}
+
+// wait waits for the requests in flight, it returns how many are left when ctx is done.
+func (d *drainer) wait(ctx context.Context) int {
+	g := d.current()
+
+	select {
+	case <-g.start():
+		return 0
+	case <-ctx.Done():
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.inFlight
+}
+
+// end removes a request added by begin.
+func (g *drainGeneration) end() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.inFlight--
+
+	if g.draining && g.inFlight == 0 {
+		close(g.idle)
+	}
+}
+
+// isDraining returns whether start has been called.
+func (g *drainGeneration) isDraining() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.draining
+}
+
+// start rejects new requests, the returned channel gets closed once
+// the requests in flight are done.
+func (g *drainGeneration) start() <-chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.draining {
+		g.draining = true
+
+		if g.inFlight == 0 {
+			close(g.idle)
+		}
+	}
+
+	return g.idle
+}
+
+// trackRequest is a hertz middleware which counts the requests in flight,
+// it runs before all other middlewares.
+//
+// While the server drains requests get rejected with "503 Service Unavailable",
+// all responses then close the connection. On HTTP/2 that makes the server
+// send a GOAWAY frame, so clients open a new connection to another node.
+func (s *Server) trackRequest(ctx context.Context, apCtx *app.RequestContext) {
+	gen := s.drain.begin()
+	if gen == nil {
+		apCtx.SetConnectionClose()
+		WriteError(apCtx, orberrors.ErrUnavailable.Wrap(ErrDraining))
+		apCtx.Abort()
+
+		return
+	}
+
+	defer gen.end()
+
+	apCtx.Next(ctx)
+
+	if gen.isDraining() {
+		apCtx.SetConnectionClose()
+	}
+}
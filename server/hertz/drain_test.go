@@ -0,0 +1,168 @@
+package hertz
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	orbserver "github.com/go-orb/go-orb/server"
+	"github.com/stretchr/testify/require"
+)
+
+const slowEndpoint = "/test.Drain/Slow"
+
+// slowHandler blocks until release gets closed.
+type slowHandler struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newSlowHandler() *slowHandler {
+	return &slowHandler{entered: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (h *slowHandler) handle(_ context.Context, req *codecMsg) (*codecMsg, error) {
+	h.entered <- struct{}{}
+	<-h.release
+
+	return req, nil
+}
+
+// withSlow adds the slow endpoint of h.
+func withSlow(h *slowHandler) orbserver.Option {
+	return withRoutes(func(s *Server) {
+		s.Router().POST(slowEndpoint, NewGRPCHandler(s, h.handle, "test.Drain", "Slow"))
+	})
+}
+
+// slowRequest calls the slow endpoint in the background, it returns the status code.
+func slowRequest(srv *Server) <-chan int {
+	codeCh := make(chan int, 1)
+
+	go func() {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, serverURL(srv, slowEndpoint),
+			bytes.NewReader([]byte(`{"text":"slow"}`)))
+		if err != nil {
+			codeCh <- 0
+			return
+		}
+
+		req.Header.Set("Content-Type", consts.MIMEApplicationJSON)
+
+		resp, err := httpClient(srv).Do(req)
+		if err != nil {
+			codeCh <- 0
+			return
+		}
+
+		_, _ = io.Copy(io.Discard, resp.Body) //nolint:errcheck
+		_ = resp.Body.Close()                 //nolint:errcheck
+
+		codeCh <- resp.StatusCode
+	}()
+
+	return codeCh
+}
+
+func TestDrain(t *testing.T) {
+	h := newSlowHandler()
+	srv := setupServer(t, WithInsecure(), WithStopTimeout(10*time.Second), withPing(), withSlow(h))
+
+	// One client, so the pings share a keep-alive connection.
+	c := httpClient(srv)
+
+	ping := func() *http.Response {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, serverURL(srv, pingPath), nil)
+		require.NoError(t, err)
+
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+
+		_, err = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		return resp
+	}
+
+	// Open a keep-alive connection.
+	require.Equal(t, http.StatusOK, ping().StatusCode)
+
+	reqCode := slowRequest(srv)
+	<-h.entered
+
+	stopErr := make(chan error, 1)
+
+	go func() {
+		stopErr <- srv.Stop(context.Background())
+	}()
+
+	// Requests on the open connection get rejected while the slow request is in flight.
+	var resp *http.Response
+
+	require.Eventually(t, func() bool {
+		resp = ping()
+		return resp.StatusCode == http.StatusServiceUnavailable
+	}, 5*time.Second, 10*time.Millisecond)
+	require.True(t, resp.Close)
+
+	select {
+	case err := <-stopErr:
+		t.Fatalf("Stop returned before the request finished: %v", err)
+	default:
+	}
+
+	close(h.release)
+
+	require.Equal(t, http.StatusOK, <-reqCode)
+	require.NoError(t, <-stopErr)
+}
+
+func TestDrainTimeout(t *testing.T) {
+	h := newSlowHandler()
+	defer close(h.release)
+
+	srv := setupServer(t, WithInsecure(), WithStopTimeout(100*time.Millisecond), withSlow(h))
+
+	reqCode := slowRequest(srv)
+	<-h.entered
+
+	err := srv.Stop(context.Background())
+	require.ErrorIs(t, err, ErrDrainTimeout)
+
+	var drainErr *DrainError
+	require.ErrorAs(t, err, &drainErr)
+	require.Equal(t, 1, drainErr.Aborted)
+
+	require.NotEqual(t, http.StatusOK, <-reqCode)
+}
+
+func TestDrainerRestart(t *testing.T) {
+	d := &drainer{}
+	d.reset()
+
+	// A request which outlives the Stop of its start.
+	aborted := d.begin()
+	require.NotNil(t, aborted)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Equal(t, 1, d.wait(ctx))
+	require.Nil(t, d.begin())
+
+	d.reset()
+
+	gen := d.begin()
+	require.NotNil(t, gen)
+
+	// Ending the aborted request doesn't touch the new start.
+	aborted.end()
+	require.Equal(t, 1, d.wait(ctx))
+
+	gen.end()
+	require.Equal(t, 0, d.wait(context.Background()))
+}
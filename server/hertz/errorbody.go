@@ -0,0 +1,133 @@
+package hertz
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// maxErrorDepth limits the number of wrapped errors sent to the client.
+const maxErrorDepth = 16
+
+// errorBody is the body of error responses, it carries the chain of wrapped errors.
+//
+// Code is only set for orberrors, it's encoded with the codec of the request,
+// codecs which can't encode it (proto) get it as google.protobuf.Struct.
+type errorBody struct {
+	Code    int        `json:"code,omitempty"`
+	Message string     `json:"message"`
+	Wrapped *errorBody `json:"wrapped,omitempty"`
+}
+
+// newErrorBody converts an error and the errors it wraps into an errorBody.
+func newErrorBody(err error) *errorBody {
+	root := &errorBody{}
+	body := root
+
+	for depth := 0; ; depth++ {
+		var next error
+
+		if orbe, ok := err.(*orberrors.Error); ok { //nolint:errorlint
+			body.Code = orbe.Code
+			body.Message = orbe.Message
+			next = orbe.Wrapped
+		} else {
+			body.Message = err.Error()
+			next = errors.Unwrap(err)
+		}
+
+		if next == nil || depth == maxErrorDepth {
+			return root
+		}
+
+		body.Wrapped = &errorBody{}
+		body = body.Wrapped
+		err = next
+	}
+}
+
+// newResponseErrorBody returns the errorBody sent to the client for the status code.
+//
+// Server errors (5xx) only carry the code and message of the orberror, the wrapped
+// errors may contain internals like queries or paths, the server logs them instead.
+func newResponseErrorBody(code int, err error) *errorBody {
+	if code < consts.StatusInternalServerError {
+		return newErrorBody(err)
+	}
+
+	body := &errorBody{Code: code, Message: orberrors.ErrInternalServerError.Message}
+	if orbe, ok := orberrors.As(err); ok {
+		body.Message = orbe.Message
+	}
+
+	return body
+}
+
+// toStruct converts the errorBody into a google.protobuf.Struct.
+func (b *errorBody) toStruct() (*structpb.Struct, error) {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]any{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	return structpb.NewStruct(m)
+}
+
+// marshal encodes the errorBody with the codec for the content type,
+// it falls back to JSON if there's no codec for it.
+func (b *errorBody) marshal(contentType string) (string, []byte, error) {
+	if codec, err := codecs.GetEncoder(contentType, b); err == nil {
+		data, err := codec.Marshal(b)
+
+		return contentType, data, err
+	}
+
+	if s, err := b.toStruct(); err == nil {
+		if codec, err := codecs.GetEncoder(contentType, s); err == nil {
+			data, err := codec.Marshal(s)
+
+			return contentType, data, err
+		}
+	}
+
+	data, err := json.Marshal(b)
+
+	return consts.MIMEApplicationJSON, data, err
+}
+
+// WriteError returns an error response to the HTTP request.
+//
+// The status code is taken from the orberror, the body contains the code,
+// message and wrapped errors, encoded with the content type the client accepts.
+// Server errors (5xx) only send the code and message of the orberror, callers
+// log the full chain.
+func WriteError(ctx *app.RequestContext, err error) {
+	if err == nil {
+		return
+	}
+
+	code := consts.StatusInternalServerError
+	if orbe, ok := orberrors.As(err); ok {
+		code = orbe.Code
+	}
+
+	ct, data, merr := newResponseErrorBody(code, err).marshal(responseContentType(ctx, nil))
+	if merr != nil {
+		ctx.AbortWithError(code, err) //nolint:errcheck
+		return
+	}
+
+	_ = ctx.Error(err) //nolint:errcheck
+	ctx.Abort()
+	ctx.Data(code, ct, data)
+}
@@ -0,0 +1,84 @@
+package hertz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseErrorBody(t *testing.T) {
+	secret := errors.New("dial tcp 10.0.0.1:5432: connection refused")
+
+	tests := []struct {
+		name string
+		code int
+		err  error
+		want *errorBody
+	}{
+		{
+			name: "client error keeps the chain",
+			code: http.StatusBadRequest,
+			err:  orberrors.ErrBadRequest.Wrap(errors.New("missing name")),
+			want: &errorBody{Code: http.StatusBadRequest, Message: "bad request", Wrapped: &errorBody{Message: "missing name"}},
+		},
+		{
+			name: "server error hides the chain",
+			code: http.StatusServiceUnavailable,
+			err:  orberrors.ErrUnavailable.Wrap(secret),
+			want: &errorBody{Code: http.StatusServiceUnavailable, Message: "service unavailable"},
+		},
+		{
+			name: "public message of a wrapped orberror",
+			code: http.StatusInternalServerError,
+			err:  orberrors.New(http.StatusInternalServerError, "database down").Wrap(secret),
+			want: &errorBody{Code: http.StatusInternalServerError, Message: "database down"},
+		},
+		{
+			name: "plain error",
+			code: http.StatusInternalServerError,
+			err:  secret,
+			want: &errorBody{Code: http.StatusInternalServerError, Message: "internal server error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, newResponseErrorBody(tt.code, tt.err))
+		})
+	}
+}
+
+func TestWriteErrorHidesInternals(t *testing.T) {
+	srv := setupServer(t, WithInsecure(), withRoutes(func(s *Server) {
+		s.Router().GET("/internal", func(_ context.Context, ctx *app.RequestContext) {
+			WriteError(ctx, orberrors.ErrInternalServerError.Wrap(errors.New("open /etc/secret: permission denied")))
+		})
+		s.Router().GET("/bad", func(_ context.Context, ctx *app.RequestContext) {
+			WriteError(ctx, orberrors.ErrBadRequest.Wrap(errors.New("missing name")))
+		})
+	}))
+
+	get := func(path string) (int, *errorBody) {
+		resp, data := doRequest(t, srv, http.MethodGet, path, nil, nil)
+
+		body := &errorBody{}
+		require.NoError(t, json.Unmarshal(data, body))
+
+		return resp.StatusCode, body
+	}
+
+	code, body := get("/internal")
+	require.Equal(t, http.StatusInternalServerError, code)
+	require.Equal(t, &errorBody{Code: http.StatusInternalServerError, Message: "internal server error"}, body)
+
+	code, body = get("/bad")
+	require.Equal(t, http.StatusBadRequest, code)
+	require.NotNil(t, body.Wrapped)
+	require.Equal(t, "missing name", body.Wrapped.Message)
+}
@@ -1,10 +1,58 @@
 package hertz
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Errors.
 var (
 	// ErrContentTypeNotSupported is returned when there is no matching codec.
 	ErrContentTypeNotSupported = errors.New("content type not supported")
-	ErrInvalidConfigType       = errors.New("http server: invalid config type provided, not of type http.Config")
+	// ErrNotAcceptable is returned when there is no codec for any of the accepted content types.
+	ErrNotAcceptable     = errors.New("none of the accepted content types is supported")
+	ErrInvalidConfigType = errors.New("http server: invalid config type provided, not of type http.Config")
+	ErrInvalidConfig     = errors.New("hertz invalid config")
+	// ErrUnsupportedEncoding is returned for unknown compression algorithms.
+	ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+	// ErrServerStopped is returned when the hertz engine stopped without an error while it should serve.
+	ErrServerStopped = errors.New("hertz server stopped unexpectedly")
+	// ErrDraining is returned for requests which arrive while the server drains on Stop.
+	ErrDraining = errors.New("hertz server is shutting down")
+	// ErrDrainTimeout is returned by Stop when requests were still in flight after the StopTimeout.
+	ErrDrainTimeout = errors.New("timeout while draining the hertz server")
 )
+
+// ConfigError is returned by New when the config contains invalid values,
+// it wraps ErrInvalidConfig.
+type ConfigError struct {
+	// Field is the yaml/json name of the config field.
+	Field string
+	// Reason describes what's wrong with the field.
+	Reason string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s: '%s' %s", ErrInvalidConfig, e.Field, e.Reason)
+}
+
+// Unwrap returns ErrInvalidConfig.
+func (e *ConfigError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+// DrainError is returned by Stop when requests had to be aborted,
+// it wraps ErrDrainTimeout.
+type DrainError struct {
+	// Aborted is the number of requests which were still in flight.
+	Aborted int
+}
+
+func (e *DrainError) Error() string {
+	return fmt.Sprintf("%s: aborted %d requests", ErrDrainTimeout, e.Aborted)
+}
+
+// Unwrap returns ErrDrainTimeout.
+func (e *DrainError) Unwrap() error {
+	return ErrDrainTimeout
+}
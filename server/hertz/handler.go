@@ -3,65 +3,131 @@ package hertz
 import (
 	"context"
 	"errors"
+	"reflect"
 	"slices"
 	"strings"
 
 	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/go-orb/go-orb/server"
 	"github.com/go-orb/go-orb/util/metadata"
-	"github.com/go-orb/go-orb/util/orberrors"
+
+	"github.com/go-orb/plugins-experimental/server/hertz/internal/orblog"
 )
 
-var stdHeaders = []string{"Accept", "Accept-Encoding", "Content-Length", "Content-Type", "User-Agent"} //nolint:gochecknoglobals
+var stdHeaders = []string{ //nolint:gochecknoglobals
+	"Accept", "Accept-Encoding", "Content-Encoding", "Content-Length", "Content-Type", "User-Agent", TimeoutHeader,
+}
 
 // Errors.
 var (
 	ErrNotHTTPServer = errors.New("server provider is not of type *http.Server")
 )
 
+// handlerOptions are the per-route options of NewGRPCHandler and NewStreamHandler.
+type handlerOptions struct {
+	// maxRequestBodyBytes overrides Config.MaxRequestBodyBytes when it's above 0.
+	maxRequestBodyBytes int
+
+	// middlewares run inside the entrypoint middlewares.
+	middlewares []server.Middleware
+}
+
+// HandlerOption configures a single route created by NewGRPCHandler or NewStreamHandler.
+type HandlerOption func(*handlerOptions)
+
+// newHandlerOptions applies the options.
+func newHandlerOptions(opts []HandlerOption) *handlerOptions {
+	o := &handlerOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
 // NewGRPCHandler wraps a gRPC function with a Hertz handler.
+//
+// It creates a server span for each request, the trace context of the client
+// gets extracted from the headers with Config.Propagator.
+//
+// The entrypoint middlewares and those of WithRouteMiddlewares wrap fHandler,
+// see chainMiddlewares for their order.
 func NewGRPCHandler[Tin any, Tout any](
 	srv *Server,
 	fHandler func(context.Context, *Tin) (*Tout, error),
 	service string,
 	method string,
+	opts ...HandlerOption,
+) func(c context.Context, ctx *app.RequestContext) {
+	srv.addOperation(&operation{
+		service: service,
+		method:  method,
+		in:      reflect.TypeFor[Tin](),
+		out:     reflect.TypeFor[Tout](),
+	})
+
+	return srv.newHandler(
+		func() any { return new(Tin) },
+		srv.decodeRequest,
+		func(ctx context.Context, req any) (any, error) {
+			return fHandler(ctx, req.(*Tin)) //nolint:errcheck
+		},
+		service,
+		method,
+		newHandlerOptions(opts),
+	)
+}
+
+// newHandler returns a Hertz handler which decodes a request created by newRequest
+// with decode, calls fHandler with the middlewares applied and encodes its result.
+// opts may be nil.
+func (s *Server) newHandler(
+	newRequest func() any,
+	decode func(apCtx *app.RequestContext, req any) error,
+	fHandler func(context.Context, any) (any, error),
+	service string,
+	method string,
+	opts *handlerOptions,
 ) func(c context.Context, ctx *app.RequestContext) {
+	maxBodySize := s.bodyLimit(opts)
+	h := chainMiddlewares(fHandler, s.routeMiddlewares(opts))
+
 	return func(ctx context.Context, apCtx *app.RequestContext) {
-		request := new(Tin)
+		s.setRequestLabels(apCtx, service, method)
 
-		if _, err := srv.decodeBody(apCtx, request); err != nil {
-			srv.logger.Error("failed to decode body", "error", err)
+		ctx, span := s.startSpan(ctx, apCtx, service, method)
+		defer endSpan(span, apCtx)
+
+		if err := readBody(apCtx, maxBodySize); err != nil {
+			s.logError(ctx, "failed to read body", err)
 			WriteError(apCtx, err)
 
 			return
 		}
 
-		// Copy metadata from req Headers into the req.Context.
-		ctx, reqMd := metadata.WithIncoming(ctx)
-		ctx, outMd := metadata.WithOutgoing(ctx)
+		request := newRequest()
 
-		apCtx.VisitAllHeaders(func(k, v []byte) {
-			sk := string(k)
-			if slices.Contains(stdHeaders, sk) {
-				return
-			}
+		if err := decode(apCtx, request); err != nil {
+			s.logError(ctx, "failed to decode body", err)
+			WriteError(apCtx, err)
 
-			reqMd[strings.ToLower(sk)] = string(v)
-		})
+			return
+		}
 
-		reqMd[metadata.Service] = service
-		reqMd[metadata.Method] = method
+		ctx, cancel, err := requestContext(ctx, apCtx)
+		if err != nil {
+			WriteError(apCtx, err)
 
-		// Apply middleware.
-		h := func(ctx context.Context, req any) (any, error) {
-			return fHandler(ctx, req.(*Tin)) //nolint:errcheck
-		}
-		for _, m := range srv.config.OptMiddlewares {
-			h = m.Call(h)
+			return
 		}
+		defer cancel()
+
+		ctx, outMd := incomingMetadata(ctx, apCtx, service, method)
 
 		out, err := h(ctx, request)
 		if err != nil {
-			srv.logger.Error("RPC request failed", "error", err)
+			span.RecordError(err)
+			s.logError(ctx, "RPC request failed", err)
 			WriteError(apCtx, err)
 
 			return
@@ -72,8 +138,8 @@ func NewGRPCHandler[Tin any, Tout any](
 			apCtx.Header(k, v)
 		}
 
-		if err := srv.encodeBody(apCtx, out); err != nil {
-			srv.logger.Error("failed to encode body", "error", err)
+		if err := s.encodeBody(apCtx, out); err != nil {
+			s.logError(ctx, "failed to encode body", err)
 			WriteError(apCtx, err)
 
 			return
@@ -81,15 +147,52 @@ func NewGRPCHandler[Tin any, Tout any](
 	}
 }
 
-// WriteError returns an error response to the HTTP request.
-func WriteError(ctx *app.RequestContext, err error) {
-	if err == nil {
-		return
+// logError logs err of a request with the trace and span ID of ctx.
+func (s *Server) logError(ctx context.Context, msg string, err error) {
+	s.logger.ErrorContext(ctx, msg, append(orblog.TraceAttrs(ctx), "error", err)...)
+}
+
+// routeMiddlewares returns the entrypoint middlewares followed by those of the route options,
+// opts may be nil.
+func (s *Server) routeMiddlewares(opts *handlerOptions) []server.Middleware {
+	if opts == nil {
+		return s.config.OptMiddlewares
 	}
 
-	if orbe, ok := orberrors.As(err); ok {
-		ctx.AbortWithError(orbe.Code, err) //nolint:errcheck
-	} else {
-		ctx.AbortWithError(500, err) //nolint:errcheck
+	return slices.Concat(s.config.OptMiddlewares, opts.middlewares)
+}
+
+// decodeRequest decodes the request of a RPC route.
+func (s *Server) decodeRequest(apCtx *app.RequestContext, req any) error {
+	_, err := s.decodeBody(apCtx, req)
+	return err
+}
+
+// incomingMetadata copies metadata from the request headers into the context,
+// it returns the context and the outgoing metadata.
+func incomingMetadata(
+	ctx context.Context,
+	apCtx *app.RequestContext,
+	service string,
+	method string,
+) (context.Context, map[string]string) {
+	ctx, reqMd := metadata.WithIncoming(ctx)
+	ctx, outMd := metadata.WithOutgoing(ctx)
+
+	apCtx.VisitAllHeaders(func(k, v []byte) {
+		sk := string(k)
+		if slices.Contains(stdHeaders, sk) {
+			return
+		}
+
+		reqMd[strings.ToLower(sk)] = string(v)
+	})
+
+	if service != "" {
+		reqMd[metadata.Service] = service
 	}
+
+	reqMd[metadata.Method] = method
+
+	return ctx, outMd
 }
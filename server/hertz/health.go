@@ -0,0 +1,308 @@
+package hertz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Health statuses, the names of grpc.health.v1.HealthCheckResponse.ServingStatus.
+const (
+	HealthServing        = "SERVING"
+	HealthNotServing     = "NOT_SERVING"
+	HealthServiceUnknown = "SERVICE_UNKNOWN"
+)
+
+// The grpc.health.v1.Health service.
+const (
+	healthService     = "grpc.health.v1.Health"
+	healthCheckMethod = "Check"
+)
+
+// ErrUnknownHealthService is returned by the health check RPC for services without a check.
+var ErrUnknownHealthService = errors.New("unknown service")
+
+// HealthCheck reports whether a dependency of the server is healthy, a non-nil
+// error makes the server not ready.
+type HealthCheck func(ctx context.Context) error
+
+// healthChecks are the named health checks of a server.
+type healthChecks struct {
+	mu     sync.RWMutex
+	names  []string
+	checks map[string]HealthCheck
+}
+
+// AddHealthCheck adds a health check to the readiness probe, registration functions
+// add their checks with it. The check is also available as service name in the
+// grpc.health.v1.Health/Check RPC, adding a check with the same name replaces it.
+//
+// Checks get removed when the server restarts, as registration functions run again.
+func (s *Server) AddHealthCheck(name string, check HealthCheck) {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+
+	if s.health.checks == nil {
+		s.health.checks = map[string]HealthCheck{}
+	}
+
+	if _, ok := s.health.checks[name]; !ok {
+		s.health.names = append(s.health.names, name)
+	}
+
+	s.health.checks[name] = check
+}
+
+// resetHealthChecks removes all health checks.
+func (s *Server) resetHealthChecks() {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+
+	s.health.names = nil
+	s.health.checks = nil
+}
+
+// Ready returns whether the server is ready to serve requests, it's true once
+// the server has been registered and false again as soon as Stop gets called.
+// The health checks don't affect it.
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// runHealthChecks runs the checks with the names, or all checks if there are none,
+// it returns the errors of the failed checks by their names.
+func (s *Server) runHealthChecks(ctx context.Context, names ...string) map[string]string {
+	s.health.mu.RLock()
+
+	if len(names) == 0 {
+		names = slices.Clone(s.health.names)
+	}
+
+	checks := make([]HealthCheck, 0, len(names))
+	for _, name := range names {
+		checks = append(checks, s.health.checks[name])
+	}
+
+	s.health.mu.RUnlock()
+
+	failed := map[string]string{}
+
+	for i, check := range checks {
+		if err := check(ctx); err != nil {
+			failed[names[i]] = err.Error()
+		}
+	}
+
+	return failed
+}
+
+// healthResponse is the body of the health routes.
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// serveLiveness answers the liveness probe, the server is alive as long as it serves.
+func (s *Server) serveLiveness(_ context.Context, ctx *app.RequestContext) {
+	writeHealth(ctx, consts.StatusOK, &healthResponse{Status: HealthServing})
+}
+
+// serveReadiness answers the readiness probe, it runs all health checks.
+func (s *Server) serveReadiness(c context.Context, ctx *app.RequestContext) {
+	if !s.Ready() {
+		writeHealth(ctx, consts.StatusServiceUnavailable, &healthResponse{Status: HealthNotServing})
+		return
+	}
+
+	failed := s.runHealthChecks(c)
+	if len(failed) > 0 {
+		writeHealth(ctx, consts.StatusServiceUnavailable, &healthResponse{Status: HealthNotServing, Checks: failed})
+		return
+	}
+
+	writeHealth(ctx, consts.StatusOK, &healthResponse{Status: HealthServing})
+}
+
+func writeHealth(ctx *app.RequestContext, code int, resp *healthResponse) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		WriteError(ctx, orberrors.ErrInternalServerError.Wrap(err))
+		return
+	}
+
+	ctx.Response.Header.Set("Cache-Control", "no-store")
+	ctx.Data(code, consts.MIMEApplicationJSONUTF8, data)
+}
+
+// healthStatus returns the status of a service for the health check RPC, the empty
+// service and the name of the server stand for the server and all its checks.
+func (s *Server) healthStatus(ctx context.Context, service string) (string, error) {
+	if !s.Ready() {
+		return HealthNotServing, nil
+	}
+
+	var names []string
+
+	if service != "" && service != s.serviceName {
+		s.health.mu.RLock()
+		_, ok := s.health.checks[service]
+		s.health.mu.RUnlock()
+
+		if !ok {
+			return "", orberrors.ErrNotFound.Wrap(fmt.Errorf("%w: '%s'", ErrUnknownHealthService, service))
+		}
+
+		names = []string{service}
+	}
+
+	if len(s.runHealthChecks(ctx, names...)) > 0 {
+		return HealthNotServing, nil
+	}
+
+	return HealthServing, nil
+}
+
+// serveHealthCheck serves grpc.health.v1.Health/Check, the messages are
+// encoded as protobuf or with protojson depending on the content type.
+func (s *Server) serveHealthCheck(c context.Context, ctx *app.RequestContext) {
+	desc, err := healthDescriptor()
+	if err != nil {
+		s.logError(c, "failed to load the health descriptor", err)
+		WriteError(ctx, orberrors.ErrInternalServerError.Wrap(err))
+		return
+	}
+
+	if err := readBody(ctx, s.config.MaxRequestBodyBytes); err != nil {
+		WriteError(ctx, err)
+		return
+	}
+
+	ct := utils.FilterContentType(string(ctx.ContentType()))
+	isProto := ct == codecs.MimeProto
+
+	req := dynamicpb.NewMessage(desc.request)
+
+	if body := ctx.Request.Body(); len(body) > 0 {
+		if isProto {
+			err = proto.Unmarshal(body, req)
+		} else {
+			err = protojson.Unmarshal(body, req)
+		}
+
+		if err != nil {
+			WriteError(ctx, orberrors.ErrBadRequest.Wrap(err))
+			return
+		}
+	}
+
+	service := req.Get(desc.request.Fields().ByName("service")).String()
+
+	status, err := s.healthStatus(c, service)
+	if err != nil {
+		WriteError(ctx, err)
+		return
+	}
+
+	resp := dynamicpb.NewMessage(desc.response)
+	resp.Set(
+		desc.response.Fields().ByName("status"),
+		protoreflect.ValueOfEnum(desc.status.Values().ByName(protoreflect.Name(status)).Number()),
+	)
+
+	var data []byte
+
+	if isProto {
+		data, err = proto.Marshal(resp)
+	} else {
+		ct = codecs.MimeJSON
+		data, err = protojson.Marshal(resp)
+	}
+
+	if err != nil {
+		s.logError(c, "failed to encode the health response", err)
+		WriteError(ctx, orberrors.ErrInternalServerError.Wrap(err))
+		return
+	}
+
+	ctx.Data(consts.StatusOK, ct, data)
+}
+
+// healthDesc are the descriptors of the grpc.health.v1 messages.
+type healthDesc struct {
+	request  protoreflect.MessageDescriptor
+	response protoreflect.MessageDescriptor
+	status   protoreflect.EnumDescriptor
+}
+
+// healthDescriptor builds the descriptors of grpc/health/v1/health.proto, they
+// don't get registered to avoid conflicts with the generated package of grpc-go.
+var healthDescriptor = sync.OnceValues(func() (*healthDesc, error) { //nolint:gochecknoglobals
+	enumValue := func(name string, number int32) *descriptorpb.EnumValueDescriptorProto {
+		return &descriptorpb.EnumValueDescriptorProto{Name: proto.String(name), Number: proto.Int32(number)}
+	}
+
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("grpc/health/v1/health.proto"),
+		Package: proto.String("grpc.health.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("HealthCheckRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name:     proto.String("service"),
+					JsonName: proto.String("service"),
+					Number:   proto.Int32(1),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				}},
+			},
+			{
+				Name: proto.String("HealthCheckResponse"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name:     proto.String("status"),
+					JsonName: proto.String("status"),
+					Number:   proto.Int32(1),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum(),
+					TypeName: proto.String(".grpc.health.v1.HealthCheckResponse.ServingStatus"),
+				}},
+				EnumType: []*descriptorpb.EnumDescriptorProto{{
+					Name: proto.String("ServingStatus"),
+					Value: []*descriptorpb.EnumValueDescriptorProto{
+						enumValue("UNKNOWN", 0),
+						enumValue(HealthServing, 1),
+						enumValue(HealthNotServing, 2),
+						enumValue(HealthServiceUnknown, 3),
+					},
+				}},
+			},
+		},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	response := fd.Messages().ByName("HealthCheckResponse")
+
+	return &healthDesc{
+		request:  fd.Messages().ByName("HealthCheckRequest"),
+		response: response,
+		status:   response.Enums().ByName("ServingStatus"),
+	}, nil
+})
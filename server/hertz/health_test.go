@@ -0,0 +1,118 @@
+package hertz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/stretchr/testify/require"
+)
+
+const healthCheckEndpoint = "/grpc.health.v1.Health/Check"
+
+// healthBody is the body of the health routes.
+type healthBody struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// probe requests the health route at path.
+func probe(t *testing.T, srv *Server, path string) (int, *healthBody) {
+	t.Helper()
+
+	resp, data := doRequest(t, srv, http.MethodGet, path, nil, nil)
+
+	out := &healthBody{}
+	require.NoError(t, json.Unmarshal(data, out))
+
+	return resp.StatusCode, out
+}
+
+func TestHealth(t *testing.T) {
+	dbErr := atomic.Pointer[error]{}
+
+	srv := setupServer(t, WithInsecure(), WithHealth(), withRoutes(func(s *Server) {
+		s.AddHealthCheck("db", func(_ context.Context) error {
+			if err := dbErr.Load(); err != nil {
+				return *err
+			}
+
+			return nil
+		})
+	}))
+	require.True(t, srv.Ready())
+
+	check := func(contentType string, body []byte) (int, []byte) {
+		resp, data := doRequest(t, srv, http.MethodPost, healthCheckEndpoint, body, map[string]string{"Content-Type": contentType})
+		return resp.StatusCode, data
+	}
+
+	code, body := probe(t, srv, DefaultHealthPath)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, HealthServing, body.Status)
+
+	code, body = probe(t, srv, DefaultReadyPath)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, HealthServing, body.Status)
+
+	code, data := check(codecs.MimeJSON, []byte(`{}`))
+	require.Equal(t, http.StatusOK, code)
+	require.JSONEq(t, `{"status":"SERVING"}`, string(data))
+
+	// HealthCheckRequest{service: "db"} and HealthCheckResponse{status: SERVING}.
+	code, data = check(codecs.MimeProto, []byte{0x0a, 0x02, 'd', 'b'})
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, []byte{0x08, 0x01}, data)
+
+	code, _ = check(codecs.MimeJSON, []byte(`{"service":"unknown"}`))
+	require.Equal(t, http.StatusNotFound, code)
+
+	failure := errors.New("connection refused")
+	dbErr.Store(&failure)
+
+	code, body = probe(t, srv, DefaultReadyPath)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, HealthNotServing, body.Status)
+	require.Equal(t, map[string]string{"db": "connection refused"}, body.Checks)
+
+	code, body = probe(t, srv, DefaultHealthPath)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, HealthServing, body.Status)
+
+	code, data = check(codecs.MimeJSON, []byte(`{"service":"db"}`))
+	require.Equal(t, http.StatusOK, code)
+	require.JSONEq(t, `{"status":"NOT_SERVING"}`, string(data))
+
+	require.NoError(t, srv.Stop(context.Background()))
+	require.False(t, srv.Ready())
+}
+
+func TestReadyDrainDelay(t *testing.T) {
+	const delay = 500 * time.Millisecond
+
+	srv := setupServer(t, WithInsecure(), WithHealth(), WithReadyDrainDelay(delay), withPing())
+
+	stopped := make(chan error, 1)
+	start := time.Now()
+
+	go func() { stopped <- srv.Stop(context.Background()) }()
+
+	// The probe fails while the server keeps serving requests.
+	require.Eventually(t, func() bool { return !srv.Ready() }, delay, time.Millisecond)
+
+	code, body := probe(t, srv, DefaultReadyPath)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, HealthNotServing, body.Status)
+
+	resp, data := doRequest(t, srv, http.MethodGet, pingPath, nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "pong", string(data))
+
+	require.NoError(t, <-stopped)
+	require.GreaterOrEqual(t, time.Since(start), delay)
+}
@@ -6,6 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/cloudwego/hertz/pkg/app/server"
 	hconfig "github.com/cloudwego/hertz/pkg/common/config"
@@ -18,6 +21,7 @@ import (
 	"github.com/go-orb/go-orb/util/addr"
 	"github.com/go-orb/plugins-experimental/server/hertz/internal/orblog"
 
+	h2config "github.com/hertz-contrib/http2/config"
 	"github.com/hertz-contrib/http2/factory"
 )
 
@@ -33,10 +37,34 @@ type Server struct {
 	logger   log.Logger
 	registry registry.Type
 
-	address string
-	hServer *server.Hertz
+	address   string
+	hServer   *server.Hertz
+	transport *listenerTransport
 
 	started bool
+
+	// registerErr collects the errors of the registration functions.
+	registerErr error
+
+	// operations are the registered handlers for the OpenAPI document.
+	operations []*operation
+
+	// ready is the state of the readiness probe, health are the checks.
+	ready  atomic.Bool
+	health healthChecks
+
+	// drain counts the requests in flight for Stop.
+	drain drainer
+
+	// metrics are collected by observeRequest.
+	metrics *serverMetrics
+
+	// done gets closed once the engine stopped serving, serveErr is
+	// the reason if it stopped without Stop being called.
+	mu       sync.Mutex
+	done     chan struct{}
+	serveErr error
+	stopping bool
 }
 
 // Start will create the listeners and start the server on the entrypoint.
@@ -47,64 +75,305 @@ func (s *Server) Start(ctx context.Context) error {
 
 	s.logger.Info("Starting", "address", s.config.Address)
 
-	// Listen and close on that address, to see which port we get.
-	l, err := net.Listen("tcp", s.config.Address)
+	ln, err := s.listen()
 	if err != nil {
 		return err
 	}
 
-	s.address = l.Addr().String()
-
-	if err := l.Close(); err != nil {
-		return err
-	}
+	s.address = ln.Addr().String()
 
 	s.logger.Info("Got address", "address", s.address)
 
 	hlog.SetLogger(orblog.NewLogger(s.logger))
 
-	hopts := []hconfig.Option{server.WithHostPorts(s.address)}
-	if s.config.H2C {
-		hopts = append(hopts, server.WithH2C(true))
+	// Hertz serves on our listener, so the address can't get lost in between.
+	s.transport = newListenerTransport(ln)
+
+	hopts, err := s.serverOptions()
+	if err != nil {
+		_ = ln.Close() //nolint:errcheck
+		return err
 	}
 
 	s.hServer = server.Default(hopts...)
+	s.hServer.Use(s.observeRequest, s.trackRequest, s.limitBody)
+	s.drain.reset()
 
 	// Register handlers.
+	s.registerErr = nil
+	s.operations = nil
+	s.resetHealthChecks()
+
 	for _, h := range s.config.OptHandlers {
 		h(s)
 	}
 
+	if s.registerErr != nil {
+		_ = ln.Close() //nolint:errcheck
+		return fmt.Errorf("failed to register the handlers: %w", s.registerErr)
+	}
+
+	if s.config.OpenAPI {
+		s.hServer.GET(s.config.OpenAPIPath, s.serveOpenAPI)
+	}
+
+	if s.config.SwaggerUI {
+		s.hServer.GET(s.config.SwaggerUIPath, s.serveSwaggerUI)
+	}
+
+	if s.config.Health {
+		s.hServer.GET(s.config.HealthPath, s.serveLiveness)
+		s.hServer.GET(s.config.ReadyPath, s.serveReadiness)
+		s.hServer.POST("/"+healthService+"/"+healthCheckMethod, s.serveHealthCheck)
+	}
+
+	if s.config.Metrics {
+		s.hServer.GET(s.config.MetricsPath, s.serveMetrics)
+	}
+
 	if s.config.H2C || s.config.HTTP2 {
-		// register http2 server factory
-		s.hServer.AddProtocol("h2", factory.NewServerFactory())
+		// register http2 server factory, with TLS it's negotiated over ALPN.
+		s.hServer.AddProtocol(alpnH2, factory.NewServerFactory(s.http2Options()...))
 	}
 
-	errCh := make(chan error)
+	errCh := make(chan error, 1)
 	go func(h *server.Hertz, errCh chan error) {
 		errCh <- h.Run()
 	}(s.hServer, errCh)
 
+	// Wait until hertz serves before registering.
+	select {
+	case <-s.transport.Serving():
+	case err := <-errCh:
+		_ = ln.Close() //nolint:errcheck
+
+		if err == nil {
+			err = ErrServerStopped
+		}
+
+		return fmt.Errorf("while starting the hertz server: %w", err)
+	case <-ctx.Done():
+		_ = ln.Close() //nolint:errcheck
+		return ctx.Err()
+	}
+
 	if err := s.registryRegister(ctx); err != nil {
+		_ = s.hServer.Close() //nolint:errcheck
 		return fmt.Errorf("failed to register the hertz server: %w", err)
 	}
 
+	s.done = make(chan struct{})
+	s.serveErr = nil
+	s.stopping = false
+
+	go s.watch(errCh)
+
 	s.started = true
+	s.ready.Store(true)
 
 	return nil
 }
 
-// Stop will stop the Hertz server(s).
+// watch waits for the engine to stop serving, when that happens without
+// Stop being called it logs the error and deregisters the entrypoint.
+func (s *Server) watch(errCh <-chan error) {
+	err := <-errCh
+
+	s.mu.Lock()
+	stopping := s.stopping
+
+	if !stopping {
+		if err == nil {
+			err = ErrServerStopped
+		}
+
+		s.serveErr = err
+	}
+	s.mu.Unlock()
+
+	if stopping {
+		close(s.done)
+		return
+	}
+
+	s.logger.Error("The hertz server stopped serving", "error", err)
+
+	s.ready.Store(false)
+
+	_ = s.transport.Close() //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.StopTimeout)
+	if derr := s.registryDeregister(ctx); derr != nil {
+		s.logger.Error("while deregistering the crashed hertz server", "error", derr)
+	}
+	cancel()
+
+	close(s.done)
+
+	if s.config.OnServeError != nil {
+		s.config.OnServeError(err)
+	}
+}
+
+// Done returns a channel which gets closed once the server stopped serving,
+// either by Stop or because of an error, see Err. It's nil before Start.
+func (s *Server) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error which made the server stop serving,
+// it's nil while the server is serving and after a regular Stop.
+func (s *Server) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.serveErr
+}
+
+// listen binds the configured address.
+func (s *Server) listen() (net.Listener, error) {
+	if !s.isUnix() {
+		return net.Listen(s.config.Network, s.config.Address)
+	}
+
+	if err := removeStaleSocket(s.config.Address); err != nil {
+		return nil, err
+	}
+
+	ln, err := net.Listen(s.config.Network, s.config.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := chmodSocket(ln.Addr().String(), s.config.SocketMode); err != nil {
+		_ = ln.Close() //nolint:errcheck
+		return nil, err
+	}
+
+	return ln, nil
+}
+
+// serverOptions maps the config onto hertz server options.
+func (s *Server) serverOptions() ([]hconfig.Option, error) {
+	hopts := []hconfig.Option{
+		server.WithNetwork(s.config.Network),
+		server.WithHostPorts(s.address),
+		server.WithTransport(s.transport.newer),
+		server.WithReadTimeout(nonNegative(s.config.ReadTimeout)),
+		server.WithWriteTimeout(nonNegative(s.config.WriteTimeout)),
+		server.WithIdleTimeout(s.idleTimeout()),
+		// Bodies get streamed, so routes can have their own limit, see limitBody.
+		server.WithStreamBody(true),
+		server.WithMaxRequestBodySize(s.config.MaxRequestBodyBytes),
+		server.WithDisablePreParseMultipartForm(true),
+	}
+
+	if s.config.MaxHeaderBytes > 0 {
+		// Hertz limits the header size with the read buffer size.
+		hopts = append(hopts, server.WithReadBufferSize(s.config.MaxHeaderBytes))
+	}
+
+	if s.config.H2C {
+		hopts = append(hopts, server.WithH2C(true))
+	}
+
+	if s.secure() {
+		tlsConfig, err := s.tlsConfig()
+		if err != nil {
+			return nil, fmt.Errorf("while creating the TLS config: %w", err)
+		}
+
+		hopts = append(hopts, server.WithTLS(tlsConfig), server.WithALPN(true))
+	}
+
+	return hopts, nil
+}
+
+// http2Options maps the config onto hertz-contrib/http2 server options.
+func (s *Server) http2Options() []h2config.Option {
+	return []h2config.Option{
+		h2config.WithMaxConcurrentStreams(uint32(s.config.MaxConcurrentStreams)), //nolint:gosec
+		h2config.WithReadTimeout(nonNegative(s.config.ReadTimeout)),
+		h2config.WithIdleTimeout(s.idleTimeout()),
+	}
+}
+
+// idleTimeout returns the IdleTimeout, falling back to ReadTimeout when it's zero.
+func (s *Server) idleTimeout() time.Duration {
+	if s.config.IdleTimeout == 0 {
+		return nonNegative(s.config.ReadTimeout)
+	}
+
+	return nonNegative(s.config.IdleTimeout)
+}
+
+// nonNegative returns 0 (no timeout) for negative durations.
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
+
+// waitReadyDrain waits ReadyDrainDelay or until ctx is done, to let load
+// balancers notice the failing readiness probe.
+func (s *Server) waitReadyDrain(ctx context.Context) {
+	if s.config.ReadyDrainDelay <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(s.config.ReadyDrainDelay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// Stop will stop the Hertz server(s), it returns the serve error
+// if the server had already stopped serving on its own.
+//
+// Stop drains the server: it stops accepting connections, requests on open
+// connections get rejected with "503 Service Unavailable" and the requests
+// in flight get up to StopTimeout to finish. When some of them had to be
+// aborted Stop returns a *DrainError with their number.
+//
+// Before that the readiness probe reports not ready for ReadyDrainDelay,
+// while the server keeps serving.
 func (s *Server) Stop(ctx context.Context) error {
 	if !s.started {
 		return nil
 	}
 
-	errChan := make(chan error)
-	defer close(errChan)
-
 	s.logger.Debug("Stopping")
 
+	// Let load balancers drain before deregistering and shutting down.
+	s.ready.Store(false)
+
+	s.mu.Lock()
+	crashed := s.serveErr != nil
+	s.stopping = true
+	s.mu.Unlock()
+
+	s.started = false
+
+	if crashed {
+		// watch deregisters the entrypoint.
+		<-s.done
+
+		if s.isUnix() {
+			if err := removeSocket(s.address); err != nil {
+				s.logger.Error("while removing the socket", "error", err)
+			}
+		}
+
+		return s.serveErr
+	}
+
+	s.waitReadyDrain(ctx)
+
 	if err := s.registryDeregister(ctx); err != nil {
 		return err
 	}
@@ -112,9 +381,42 @@ func (s *Server) Stop(ctx context.Context) error {
 	stopCtx, cancel := context.WithTimeoutCause(ctx, s.config.StopTimeout, errors.New("timeout while stopping the hertz server"))
 	defer cancel()
 
-	s.started = false
+	if err := s.transport.StopAccepting(); err != nil {
+		s.logger.Error("while closing the listener", "error", err)
+	}
+
+	aborted := s.drain.wait(stopCtx)
+
+	// Requests would be rejected anyway, don't wait for keep-alive connections.
+	s.transport.CloseIdle()
+
+	if err := s.hServer.Shutdown(stopCtx); err != nil && aborted == 0 {
+		return err
+	}
+
+	if aborted > 0 {
+		s.logger.Warn("Aborted requests while draining", "aborted", aborted, "timeout", s.config.StopTimeout)
+	}
 
-	return s.hServer.Shutdown(stopCtx)
+	select {
+	case <-s.done:
+	case <-stopCtx.Done():
+		if aborted == 0 {
+			return context.Cause(stopCtx)
+		}
+	}
+
+	if s.isUnix() {
+		if err := removeSocket(s.address); err != nil {
+			return err
+		}
+	}
+
+	if aborted > 0 {
+		return &DrainError{Aborted: aborted}
+	}
+
+	return nil
 }
 
 // AddHandler adds a handler for later registration.
@@ -132,13 +434,18 @@ func (s *Server) Network() string {
 	return s.config.Network
 }
 
-// Address returns the address the entrypoint is listening on.
+// Address returns the address the entrypoint is listening on,
+// for unix sockets that's the path of the socket.
 func (s *Server) Address() string {
 	return s.address
 }
 
-// Transport returns the client transport to use.
+// Transport returns the client transport to use, see Config.ClientTransport.
 func (s *Server) Transport() string {
+	if s.config.ClientTransport != "" {
+		return s.config.ClientTransport
+	}
+
 	if s.config.H2C {
 		return "hertzh2c"
 	} else if !s.config.Insecure {
@@ -228,15 +535,22 @@ func New(
 		return nil, fmt.Errorf("hertz invalid config: %v", cfg)
 	}
 
-	var err error
-
-	cfg.Address, err = addr.GetAddress(cfg.Address)
-	if err != nil {
-		return nil, fmt.Errorf("hertz validate addr '%s': %w", cfg.Address, err)
+	if err := cfg.validate(); err != nil {
+		return nil, err
 	}
 
-	if err := addr.ValidateAddress(cfg.Address); err != nil {
-		return nil, err
+	// Unix sockets use a path as address.
+	if cfg.Network != NetworkUnix {
+		var err error
+
+		cfg.Address, err = addr.GetAddress(cfg.Address)
+		if err != nil {
+			return nil, fmt.Errorf("hertz validate addr '%s': %w", cfg.Address, err)
+		}
+
+		if err := addr.ValidateAddress(cfg.Address); err != nil {
+			return nil, err
+		}
 	}
 
 	entrypoint := Server{
@@ -247,6 +561,8 @@ func New(
 		config:   cfg,
 		logger:   logger,
 		registry: reg,
+
+		metrics: newServerMetrics(),
 	}
 
 	return &entrypoint, nil
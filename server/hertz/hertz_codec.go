@@ -3,12 +3,15 @@ package hertz
 import (
 	"fmt"
 	"mime"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/common/utils"
 	"github.com/cloudwego/hertz/pkg/protocol/consts"
 	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/util/orberrors"
 )
 
 // TODO(jochumdev): decode body now also does content type setting, maybe separate that out
@@ -23,65 +26,239 @@ func GetContentType(header string) (string, error) {
 	return ct, nil
 }
 
-// GetAcceptType parses the Accept header and checks against the available codecs
-// to find a matching content type.
+// acceptRange is a media range of the Accept header.
+type acceptRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// parseAccept parses the media ranges of an Accept header, invalid ranges are skipped.
+func parseAccept(header string) []acceptRange {
+	ranges := []acceptRange{}
+
+	for _, part := range strings.Split(header, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		typ, subtype, ok := strings.Cut(mt, "/")
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(v, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+
+		ranges = append(ranges, acceptRange{typ: typ, subtype: subtype, q: q})
+	}
+
+	return ranges
+}
+
+// acceptQuality returns the quality of the most specific range which matches the
+// content type and the index of that range, the index is -1 if none matches.
+func acceptQuality(ranges []acceptRange, contentType string) (float64, int) {
+	typ, subtype, _ := strings.Cut(contentType, "/")
+
+	quality, index, specificity := 0.0, -1, -1
+
+	for i, r := range ranges {
+		s := -1
+
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		}
+
+		if s > specificity {
+			quality, index, specificity = r.q, i, s
+		}
+	}
+
+	return quality, index
+}
+
+// GetAcceptType negotiates the response content type out of the codecs by
+// their content types and the Accept header, following RFC 9110.
+//
+// The content type with the highest quality wins, the quality comes from the
+// most specific matching range, "type/subtype" before "type/*" before "*/*".
+// Ties prefer the content type of the request, then the order of the Accept
+// header. Without an Accept header the content type of the request is used.
+//
+// It returns an empty string if none of the codecs is acceptable.
 func GetAcceptType(ctx codecs.Map, acceptHeader string, contentType string) string {
-	accept := contentType
+	contentType = utils.FilterContentType(contentType)
 
 	// If request used Form content type, return JSON instead of form.
-	if accept == consts.MIMEApplicationHTMLFormUTF8 {
-		accept = consts.MIMEApplicationJSONUTF8
+	if contentType == consts.MIMEApplicationHTMLForm || contentType == consts.MIMEMultipartPOSTForm {
+		contentType = consts.MIMEApplicationJSON
 	}
 
-	// If explicitly asked for a specific content type, use that
-	acceptSlice := strings.Split(acceptHeader, ",")
-	for _, acceptType := range acceptSlice {
-		ct, _, err := mime.ParseMediaType(acceptType)
-		if err != nil {
+	ranges := parseAccept(acceptHeader)
+	if len(ranges) == 0 {
+		// No Accept header, any content type is acceptable.
+		ranges = []acceptRange{{typ: "*", subtype: "*", q: 1}}
+	}
+
+	accept, bestQ, bestIndex := "", 0.0, -1
+
+	for _, ct := range supportedTypes(ctx) {
+		q, index := acceptQuality(ranges, ct)
+		if q <= 0 {
 			continue
 		}
 
-		// Check if we have a codec for the content type
-		if _, ok := ctx[ct]; ok {
-			accept = ct
+		switch {
+		case q > bestQ:
+		case q < bestQ:
+			continue
+		case accept == contentType:
+			continue
+		case ct != contentType && index >= bestIndex:
+			continue
 		}
+
+		accept, bestQ, bestIndex = ct, q, index
 	}
 
 	return accept
 }
 
-// decodeBody takes the request body and decodes it into the proto type.
+// supportedTypes returns the sorted content types of the codecs.
+func supportedTypes(m codecs.Map) []string {
+	types := make([]string, 0, len(m))
+	for ct := range m {
+		types = append(types, ct)
+	}
+
+	slices.Sort(types)
+
+	return types
+}
+
+// codecsByMime returns the registered codecs by their content types,
+// when v isn't nil only the codecs which are able to encode it.
+func codecsByMime(v any) codecs.Map {
+	m := codecs.Map{}
+
+	codecs.Plugins.Range(func(_ string, codec codecs.Marshaler) bool {
+		if v != nil && !codec.Marshals(v) {
+			return true
+		}
+
+		for _, ct := range codec.ContentTypes() {
+			if _, ok := m[ct]; !ok {
+				m[ct] = codec
+			}
+		}
+
+		return true
+	})
+
+	return m
+}
+
+// responseContentType negotiates the content type for the response,
+// it's empty if we have no codec for v which the client accepts.
+func responseContentType(ctx *app.RequestContext, v any) string {
+	ct := string(ctx.ContentType())
+	if ctx.Request.Header.IsGet() || ct == "" {
+		ct = consts.MIMEApplicationJSON
+	}
+
+	return GetAcceptType(codecsByMime(v), string(ctx.Request.Header.Peek(consts.HeaderAccept)), ct)
+}
+
+// codecError returns the error for a missing codec.
+func codecError(code int, ct string) error {
+	if codecs.Plugins.Len() == 0 {
+		return orberrors.ErrInternalServerError.Wrap(ErrNoMatchingCodecs)
+	}
+
+	return orberrors.HTTP(code).Wrap(fmt.Errorf("%w: '%s'", ErrContentTypeNotSupported, ct))
+}
+
+// decodeBody decodes the request body into msg with the codec for its content type.
+// Query parameters and forms get bound with the hertz binders.
 func (s *Server) decodeBody(ctx *app.RequestContext, msg any) (string, error) {
 	if ctx.Request.Header.IsGet() {
-		return consts.MIMEApplicationJSON, ctx.BindQuery(msg)
+		if err := ctx.BindQuery(msg); err != nil {
+			return "", orberrors.ErrBadRequest.Wrap(err)
+		}
+
+		return consts.MIMEApplicationJSON, nil
 	}
 
+	return s.unmarshalBody(ctx, msg)
+}
+
+// unmarshalBody decodes the request body into msg regardless of the method,
+// the body has been read and decompressed by readBody already.
+func (s *Server) unmarshalBody(ctx *app.RequestContext, msg any) (string, error) {
 	ct := utils.FilterContentType(string(ctx.ContentType()))
+
 	switch ct {
-	case consts.MIMEApplicationJSON:
-		return ct, ctx.BindJSON(msg)
-	case consts.MIMEPROTOBUF:
-		return ct, ctx.BindProtobuf(msg)
 	case consts.MIMEApplicationHTMLForm, consts.MIMEMultipartPOSTForm:
-		return consts.MIMEApplicationJSON, ctx.BindForm(msg)
-	default:
-		return consts.MIMETextPlain, fmt.Errorf("%w: '%s'", ErrContentTypeNotSupported, ct)
+		if err := ctx.BindForm(msg); err != nil {
+			return "", orberrors.ErrBadRequest.Wrap(err)
+		}
+
+		return consts.MIMEApplicationJSON, nil
+	}
+
+	codec, err := codecs.GetDecoder(ct, msg)
+	if err != nil {
+		return "", codecError(consts.StatusUnsupportedMediaType, ct)
+	}
+
+	if err := codec.Unmarshal(ctx.Request.Body(), msg); err != nil {
+		return "", orberrors.ErrBadRequest.Wrap(err)
 	}
+
+	return ct, nil
 }
 
-// encodeBody takes the return proto type and encodes it into the response.
+// encodeBody encodes v with the negotiated content type into the response.
 func (s *Server) encodeBody(ctx *app.RequestContext, v any) error {
-	ct := utils.FilterContentType(ctx.Request.Header.Get(consts.HeaderAccept))
+	ct := responseContentType(ctx, v)
+	if ct == "" {
+		if codecs.Plugins.Len() == 0 {
+			return orberrors.ErrInternalServerError.Wrap(ErrNoMatchingCodecs)
+		}
 
-	switch ct {
-	case consts.MIMEApplicationJSON:
-		ctx.JSON(consts.StatusOK, v)
-		return nil
-	case consts.MIMEPROTOBUF:
-		ctx.ProtoBuf(consts.StatusOK, v)
-	default:
-		return fmt.Errorf("%w: '%s'", ErrContentTypeNotSupported, ct)
+		return orberrors.HTTP(consts.StatusNotAcceptable).Wrap(
+			fmt.Errorf("%w, supported: %s", ErrNotAcceptable, strings.Join(supportedTypes(codecsByMime(v)), ", ")),
+		)
 	}
 
+	codec, err := codecs.GetEncoder(ct, v)
+	if err != nil {
+		return codecError(consts.StatusNotAcceptable, ct)
+	}
+
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return orberrors.ErrInternalServerError.Wrap(err)
+	}
+
+	data, err = s.compressBody(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	ctx.Data(consts.StatusOK, ct, data)
+
 	return nil
 }
@@ -0,0 +1,264 @@
+package hertz
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/codecs"
+	orbserver "github.com/go-orb/go-orb/server"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+const codecEndpoint = "/test.Codec/Echo"
+
+// jsonCodec is a minimal JSON codec, the codec plugins aren't a dependency of the server.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Marshals(_ any) bool                { return true }
+func (jsonCodec) Unmarshals(_ any) bool              { return true }
+func (jsonCodec) ContentTypes() []string             { return []string{consts.MIMEApplicationJSON} }
+func (jsonCodec) Name() string                       { return "json" }
+func (jsonCodec) Exts() []string                     { return []string{".json"} }
+
+func (jsonCodec) NewDecoder(r io.Reader) codecs.Decoder {
+	return codecs.DecoderFunc(json.NewDecoder(r).Decode)
+}
+
+func (jsonCodec) NewEncoder(w io.Writer) codecs.Encoder {
+	return codecs.EncoderFunc(json.NewEncoder(w).Encode)
+}
+
+// protoCodec is a minimal protobuf codec.
+type protoCodec struct{}
+
+func (protoCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, codecs.ErrUnknownValueType
+	}
+
+	return proto.Marshal(m)
+}
+
+func (protoCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return codecs.ErrUnknownValueType
+	}
+
+	return proto.Unmarshal(data, m)
+}
+
+func (protoCodec) Marshals(v any) bool {
+	_, ok := v.(proto.Message)
+	return ok
+}
+
+func (c protoCodec) Unmarshals(v any) bool             { return c.Marshals(v) }
+func (protoCodec) ContentTypes() []string              { return []string{codecs.MimeProto} }
+func (protoCodec) Name() string                        { return "proto" }
+func (protoCodec) Exts() []string                      { return []string{".pb"} }
+func (protoCodec) NewDecoder(io.Reader) codecs.Decoder { return nil }
+func (protoCodec) NewEncoder(io.Writer) codecs.Encoder { return nil }
+
+func init() { //nolint:gochecknoinits
+	codecs.Register("json", jsonCodec{})
+	codecs.Register("proto", protoCodec{})
+}
+
+type codecMsg struct {
+	Text string `json:"text"`
+}
+
+func echoCodecMsg(_ context.Context, req *codecMsg) (*codecMsg, error) {
+	return req, nil
+}
+
+// withCodecEcho adds the POST route codecEndpoint which echos a codecMsg.
+func withCodecEcho() orbserver.Option {
+	return withRoutes(func(s *Server) {
+		s.Router().POST(codecEndpoint, NewGRPCHandler(s, echoCodecMsg, "test.Codec", "Echo"))
+	})
+}
+
+func TestCodecNegotiation(t *testing.T) {
+	srv := setupServer(t, WithInsecure(), withCodecEcho())
+
+	tests := []struct {
+		name        string
+		contentType string
+		accept      string
+		code        int
+		// messages is the chain of messages of the error body.
+		messages []string
+	}{
+		{name: "json", contentType: consts.MIMEApplicationJSON, code: http.StatusOK},
+		{name: "any", contentType: consts.MIMEApplicationJSON, accept: "*/*", code: http.StatusOK},
+		{
+			name:        "unsupported content type",
+			contentType: "text/csv",
+			code:        http.StatusUnsupportedMediaType,
+			messages:    []string{"unsupported media type", "content type not supported: 'text/csv'", "content type not supported"},
+		},
+		{
+			name:        "unsupported accept",
+			contentType: consts.MIMEApplicationJSON,
+			accept:      "text/csv, application/xml;q=0.5",
+			code:        http.StatusNotAcceptable,
+			messages: []string{
+				"not acceptable",
+				"none of the accepted content types is supported, supported: application/json",
+				"none of the accepted content types is supported",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := map[string]string{"Content-Type": tt.contentType}
+			if tt.accept != "" {
+				header["Accept"] = tt.accept
+			}
+
+			resp, data := doRequest(t, srv, http.MethodPost, codecEndpoint, []byte(`{"text":"hello"}`), header)
+			require.Equal(t, tt.code, resp.StatusCode)
+			require.Equal(t, consts.MIMEApplicationJSON, resp.Header.Get("Content-Type"))
+
+			if tt.code == http.StatusOK {
+				require.JSONEq(t, `{"text":"hello"}`, string(data))
+				return
+			}
+
+			body := &errorBody{}
+			require.NoError(t, json.Unmarshal(data, body))
+			require.Equal(t, tt.code, body.Code)
+
+			messages := []string{}
+			for b := body; b != nil; b = b.Wrapped {
+				messages = append(messages, b.Message)
+			}
+
+			require.Equal(t, tt.messages, messages)
+		})
+	}
+}
+
+func TestParseAccept(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []acceptRange
+	}{
+		{name: "empty", header: "", want: []acceptRange{}},
+		{name: "single", header: "application/json", want: []acceptRange{{typ: "application", subtype: "json", q: 1}}},
+		{
+			name:   "q-values",
+			header: "application/json, application/x-protobuf;q=0.9",
+			want: []acceptRange{
+				{typ: "application", subtype: "json", q: 1},
+				{typ: "application", subtype: "x-protobuf", q: 0.9},
+			},
+		},
+		{
+			name:   "wildcards",
+			header: "text/*;q=0.5, */*;q=0.1",
+			want:   []acceptRange{{typ: "text", subtype: "*", q: 0.5}, {typ: "*", subtype: "*", q: 0.1}},
+		},
+		{name: "q=0", header: "application/json;q=0", want: []acceptRange{{typ: "application", subtype: "json", q: 0}}},
+		{name: "other params", header: "text/plain; charset=utf-8", want: []acceptRange{{typ: "text", subtype: "plain", q: 1}}},
+		{
+			name:   "malformed ranges get skipped",
+			header: "json, application/json;q=abc, application/json;q=2, application/json;q=-1, ;;, application/yaml;q=0.3",
+			want:   []acceptRange{{typ: "application", subtype: "yaml", q: 0.3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, parseAccept(tt.header))
+		})
+	}
+}
+
+func TestAcceptQuality(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		ct     string
+		q      float64
+		index  int
+	}{
+		{name: "exact", header: "application/json", ct: "application/json", q: 1, index: 0},
+		{name: "no match", header: "text/plain", ct: "application/json", q: 0, index: -1},
+		{name: "any", header: "*/*;q=0.4", ct: "application/json", q: 0.4, index: 0},
+		{name: "type wildcard before any", header: "*/*;q=0.9, application/*;q=0.2", ct: "application/json", q: 0.2, index: 1},
+		{name: "exact before type wildcard", header: "application/*;q=0.9, application/json;q=0.5", ct: "application/json", q: 0.5, index: 1},
+		{name: "q=0 excludes", header: "*/*, application/json;q=0", ct: "application/json", q: 0, index: 1},
+		{name: "first of equal ranges", header: "application/json;q=0.3, application/json;q=0.8", ct: "application/json", q: 0.3, index: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, index := acceptQuality(parseAccept(tt.header), tt.ct)
+			require.InDelta(t, tt.q, q, 0.0001)
+			require.Equal(t, tt.index, index)
+		})
+	}
+}
+
+func TestGetAcceptType(t *testing.T) {
+	m := codecs.Map{
+		"application/json":       jsonCodec{},
+		"application/x-protobuf": jsonCodec{},
+		"application/yaml":       jsonCodec{},
+	}
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		want        string
+	}{
+		{name: "no accept uses the content type", contentType: "application/yaml", want: "application/yaml"},
+		{name: "malformed accept uses the content type", accept: "json", contentType: "application/yaml", want: "application/yaml"},
+		{name: "form requests get json", contentType: consts.MIMEApplicationHTMLForm, want: "application/json"},
+		{name: "content type params", contentType: "application/yaml; charset=utf-8", want: "application/yaml"},
+		{name: "exact", accept: "application/x-protobuf", contentType: "application/json", want: "application/x-protobuf"},
+		{
+			name:        "highest quality",
+			accept:      "application/json;q=0.5, application/x-protobuf",
+			contentType: "application/json",
+			want:        "application/x-protobuf",
+		},
+		{name: "any prefers the content type", accept: "*/*", contentType: "application/x-protobuf", want: "application/x-protobuf"},
+		{name: "type wildcard prefers the content type", accept: "application/*", contentType: "application/yaml", want: "application/yaml"},
+		{
+			name:        "ties follow the accept order",
+			accept:      "application/yaml, application/json",
+			contentType: "text/plain",
+			want:        "application/yaml",
+		},
+		{
+			name:        "ties without order use the sorted types",
+			accept:      "*/*;q=0.8, application/json;q=0",
+			contentType: "application/json",
+			want:        "application/x-protobuf",
+		},
+		{name: "q=0 excludes the content type", accept: "application/json;q=0", contentType: "application/json", want: ""},
+		{name: "nothing matches", accept: "text/*", contentType: "application/json", want: ""},
+		{name: "only unacceptable ranges", accept: "text/html, */*;q=0", contentType: "application/json", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, GetAcceptType(m, tt.accept, tt.contentType))
+		})
+	}
+}
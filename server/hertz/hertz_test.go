@@ -0,0 +1,228 @@
+package hertz
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/log"
+	"github.com/go-orb/go-orb/registry"
+	orbserver "github.com/go-orb/go-orb/server"
+	"github.com/go-orb/go-orb/types"
+	"github.com/stretchr/testify/require"
+)
+
+// pingPath is the route of withPing.
+const pingPath = "/ping"
+
+// withRoutes adds the routes of a test with register.
+func withRoutes(register func(s *Server)) orbserver.Option {
+	return WithHandlers(func(srv any) {
+		s, ok := srv.(*Server)
+		if ok {
+			register(s)
+		}
+	})
+}
+
+// withPing adds a GET route which answers "pong".
+func withPing() orbserver.Option {
+	return withRoutes(func(s *Server) {
+		s.Router().GET(pingPath, func(_ context.Context, ctx *app.RequestContext) {
+			ctx.String(consts.StatusOK, "pong")
+		})
+	})
+}
+
+// newTestServer creates an entrypoint with the options without starting it.
+func newTestServer(t *testing.T, opts ...orbserver.Option) *Server {
+	t.Helper()
+
+	if os.Getenv("CI") != "" {
+		t.Skip("Skipping testing in CI environment")
+	}
+
+	logger, err := log.New()
+	require.NoError(t, err)
+
+	reg, err := registry.New(nil, &types.Components{}, logger)
+	require.NoError(t, err)
+
+	ep, err := New("test.hertz", "v1.0.0", "hertztest", NewConfig(opts...), logger, reg)
+	require.NoError(t, err)
+
+	srv, ok := ep.(*Server)
+	require.True(t, ok)
+
+	return srv
+}
+
+// setupServer starts an entrypoint with the options, it gets stopped once the test is done.
+func setupServer(t *testing.T, opts ...orbserver.Option) *Server {
+	t.Helper()
+
+	srv := newTestServer(t, opts...)
+
+	ctx := context.Background()
+	require.NoError(t, srv.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, srv.Stop(ctx))
+	})
+
+	return srv
+}
+
+// httpClient returns a HTTP/1 client for the entrypoint, it accepts any certificate.
+func httpClient(srv *Server) *http.Client {
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+		// An empty map disables HTTP/2.
+		TLSNextProto: map[string]func(string, *tls.Conn) http.RoundTripper{},
+	}
+
+	if srv.isUnix() {
+		tr.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, NetworkUnix, srv.Address())
+		}
+	}
+
+	return &http.Client{Transport: tr}
+}
+
+// serverURL returns the URL of the path on the entrypoint.
+func serverURL(srv *Server, path string) string {
+	scheme := "https"
+	if !srv.secure() {
+		scheme = "http"
+	}
+
+	if srv.isUnix() {
+		return scheme + "://localhost" + path
+	}
+
+	return scheme + "://" + srv.Address() + path
+}
+
+// doRequest sends a request with the headers to the path on the entrypoint,
+// it returns the response with its body.
+func doRequest(
+	t *testing.T,
+	srv *Server,
+	method string,
+	path string,
+	body []byte,
+	header map[string]string,
+) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), method, serverURL(srv, path), bytes.NewReader(body))
+	require.NoError(t, err)
+
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient(srv).Do(req)
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	return resp, data
+}
+
+// deregisterRecorder records the nodes which get deregistered.
+type deregisterRecorder struct {
+	registry.Registry
+
+	deregistered chan registry.ServiceNode
+}
+
+func (r *deregisterRecorder) Deregister(ctx context.Context, node registry.ServiceNode) error {
+	r.deregistered <- node
+
+	return r.Registry.Deregister(ctx, node)
+}
+
+func TestServeCrash(t *testing.T) {
+	if os.Getenv("CI") != "" {
+		t.Skip("Skipping testing in CI environment")
+	}
+
+	logger, err := log.New()
+	require.NoError(t, err)
+
+	reg, err := registry.New(nil, &types.Components{}, logger)
+	require.NoError(t, err)
+
+	rec := &deregisterRecorder{Registry: reg.Registry, deregistered: make(chan registry.ServiceNode, 2)}
+	serveErr := make(chan error, 1)
+
+	ep, err := New("test.hertz", "v1.0.0", "hertztest",
+		NewConfig(
+			WithInsecure(),
+			WithOnServeError(func(err error) { serveErr <- err }),
+		),
+		logger,
+		registry.Type{Registry: rec},
+	)
+	require.NoError(t, err)
+
+	srv, ok := ep.(*Server)
+	require.True(t, ok)
+
+	ctx := context.Background()
+	require.NoError(t, srv.Start(ctx))
+
+	require.True(t, srv.Ready())
+	require.NoError(t, srv.Err())
+
+	select {
+	case <-srv.Done():
+		t.Fatal("Done is closed while the server serves")
+	default:
+	}
+
+	address := srv.Address()
+
+	// Break the listener behind the back of hertz.
+	require.NoError(t, srv.transport.ln.Close())
+
+	select {
+	case <-srv.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Done didn't get closed after the crash")
+	}
+
+	err = <-serveErr
+	require.Error(t, err)
+	require.Equal(t, err, srv.Err())
+	require.False(t, srv.Ready())
+
+	node := <-rec.deregistered
+	require.Equal(t, address, node.Address)
+
+	// Stop returns the error of the crash and doesn't deregister again.
+	require.ErrorIs(t, srv.Stop(ctx), err)
+	require.Empty(t, rec.deregistered)
+
+	// The entrypoint starts again after the crash.
+	require.NoError(t, srv.Start(ctx))
+	require.True(t, srv.Ready())
+	require.NoError(t, srv.Err())
+
+	require.NoError(t, srv.Stop(ctx))
+	require.NoError(t, srv.Err())
+	require.Len(t, rec.deregistered, 1)
+	require.Empty(t, serveErr)
+}
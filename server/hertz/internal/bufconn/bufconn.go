@@ -0,0 +1,266 @@
+// Package bufconn implements hertz' buffered network.Conn on top of a net.Conn.
+//
+// The hertz client transport has the same package, the modules don't depend on each other.
+package bufconn
+
+import (
+	"errors"
+	"net"
+	"syscall"
+	"time"
+
+	herrors "github.com/cloudwego/hertz/pkg/common/errors"
+	"github.com/cloudwego/hertz/pkg/network"
+)
+
+// Default sizes of the connection buffers, the same as net/http uses.
+const (
+	DefaultReadBufferSize  = 4096
+	DefaultWriteBufferSize = 4096
+)
+
+var (
+	_ network.Conn               = (*Conn)(nil)
+	_ network.ErrorNormalization = (*Conn)(nil)
+)
+
+// Conn implements hertz' buffered network.Conn on top of a net.Conn,
+// embed it to add connection handling.
+//
+// Slices returned by Peek stay valid until Release gets called,
+// slices returned by Malloc until Flush gets called.
+type Conn struct {
+	net.Conn
+
+	// rbuf[off:] is the buffered input which hasn't been consumed yet.
+	rbuf []byte
+	off  int
+
+	// wbufs is the buffered output, wbuf is the buffer which gets
+	// filled by Malloc and WriteBinary, it's not in wbufs yet.
+	wbufs net.Buffers
+	wbuf  []byte
+
+	readBufferSize  int
+	writeBufferSize int
+}
+
+// New wraps c, buffer sizes of 0 or less use the defaults.
+func New(c net.Conn, readBufferSize, writeBufferSize int) *Conn {
+	if readBufferSize <= 0 {
+		readBufferSize = DefaultReadBufferSize
+	}
+
+	if writeBufferSize <= 0 {
+		writeBufferSize = DefaultWriteBufferSize
+	}
+
+	return &Conn{
+		Conn:            c,
+		readBufferSize:  readBufferSize,
+		writeBufferSize: writeBufferSize,
+	}
+}
+
+// NetConn returns the wrapped connection.
+func (c *Conn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// fill reads from the connection until at least n bytes are buffered.
+func (c *Conn) fill(n int) error {
+	for c.Len() < n {
+		if cap(c.rbuf)-len(c.rbuf) < c.readBufferSize {
+			// Move to a new buffer, previously peeked slices must stay untouched.
+			nb := make([]byte, c.Len(), 2*c.Len()+max(n, c.readBufferSize))
+			copy(nb, c.rbuf[c.off:])
+			c.rbuf = nb
+			c.off = 0
+		}
+
+		m, err := c.Conn.Read(c.rbuf[len(c.rbuf):cap(c.rbuf)])
+		c.rbuf = c.rbuf[:len(c.rbuf)+m]
+
+		if err != nil && c.Len() < n {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Conn) Peek(n int) ([]byte, error) {
+	if err := c.fill(n); err != nil {
+		return nil, err
+	}
+
+	return c.rbuf[c.off : c.off+n], nil
+}
+
+func (c *Conn) Skip(n int) error {
+	if err := c.fill(n); err != nil {
+		return err
+	}
+
+	c.off += n
+
+	return nil
+}
+
+func (c *Conn) Release() error {
+	if c.off == len(c.rbuf) {
+		c.rbuf = c.rbuf[:0]
+		c.off = 0
+
+		return nil
+	}
+
+	n := copy(c.rbuf, c.rbuf[c.off:])
+	c.rbuf = c.rbuf[:n]
+	c.off = 0
+
+	return nil
+}
+
+func (c *Conn) Len() int {
+	return len(c.rbuf) - c.off
+}
+
+func (c *Conn) ReadByte() (byte, error) {
+	if err := c.fill(1); err != nil {
+		return 0, err
+	}
+
+	b := c.rbuf[c.off]
+	c.off++
+
+	return b, nil
+}
+
+func (c *Conn) ReadBinary(n int) ([]byte, error) {
+	if err := c.fill(n); err != nil {
+		return nil, err
+	}
+
+	p := make([]byte, n)
+	copy(p, c.rbuf[c.off:])
+	c.off += n
+
+	return p, nil
+}
+
+// Read returns buffered data first and reads from the connection else.
+func (c *Conn) Read(p []byte) (int, error) {
+	if c.Len() > 0 {
+		n := copy(p, c.rbuf[c.off:])
+		c.off += n
+
+		return n, nil
+	}
+
+	return c.Conn.Read(p)
+}
+
+// Write flushes the buffered output and writes directly to the connection.
+func (c *Conn) Write(p []byte) (int, error) {
+	if err := c.Flush(); err != nil {
+		return 0, err
+	}
+
+	return c.Conn.Write(p)
+}
+
+// Malloc returns a slice of the output buffer.
+func (c *Conn) Malloc(n int) ([]byte, error) {
+	return c.alloc(n), nil
+}
+
+// WriteBinary buffers b, b itself gets buffered when it's larger than the
+// write buffer, it must not be modified until Flush then.
+func (c *Conn) WriteBinary(b []byte) (int, error) {
+	if len(b) < c.writeBufferSize {
+		return copy(c.alloc(len(b)), b), nil
+	}
+
+	c.pushBuf()
+	c.wbufs = append(c.wbufs, b)
+
+	return len(b), nil
+}
+
+// alloc returns n bytes of the output buffer, the buffers never get
+// reallocated so earlier slices stay valid.
+func (c *Conn) alloc(n int) []byte {
+	if cap(c.wbuf)-len(c.wbuf) < n {
+		c.pushBuf()
+		c.wbuf = make([]byte, 0, max(n, c.writeBufferSize))
+	}
+
+	c.wbuf = c.wbuf[:len(c.wbuf)+n]
+
+	return c.wbuf[len(c.wbuf)-n : len(c.wbuf) : len(c.wbuf)]
+}
+
+// pushBuf moves the current buffer to the buffers to flush.
+func (c *Conn) pushBuf() {
+	if len(c.wbuf) > 0 {
+		c.wbufs = append(c.wbufs, c.wbuf)
+	}
+
+	c.wbuf = nil
+}
+
+// Flush writes the buffered output with a single writev.
+func (c *Conn) Flush() error {
+	last := c.wbuf
+	c.pushBuf()
+
+	if len(c.wbufs) == 0 {
+		c.wbuf = last
+
+		return nil
+	}
+
+	bufs := c.wbufs
+	_, err := bufs.WriteTo(c.Conn)
+
+	clear(c.wbufs)
+	c.wbufs = c.wbufs[:0]
+
+	// Reuse the buffer, but don't hold on to buffers which grew for large writes.
+	if cap(last) == c.writeBufferSize {
+		c.wbuf = last[:0]
+	}
+
+	return err
+}
+
+func (c *Conn) SetReadTimeout(t time.Duration) error {
+	if t <= 0 {
+		return c.Conn.SetReadDeadline(time.Time{})
+	}
+
+	return c.Conn.SetReadDeadline(time.Now().Add(t))
+}
+
+func (c *Conn) SetWriteTimeout(t time.Duration) error {
+	if t <= 0 {
+		return c.Conn.SetWriteDeadline(time.Time{})
+	}
+
+	return c.Conn.SetWriteDeadline(time.Now().Add(t))
+}
+
+// ToHertzError maps connection errors to the errors hertz expects.
+func (c *Conn) ToHertzError(err error) error {
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ENOTCONN) {
+		return herrors.ErrConnectionClosed
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return herrors.ErrTimeout
+	}
+
+	return err
+}
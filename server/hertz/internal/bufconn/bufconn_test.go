@@ -0,0 +1,169 @@
+package bufconn
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	herrors "github.com/cloudwego/hertz/pkg/common/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// pipeConn returns a Conn with the read buffer size whose peer writes the chunks one by one.
+func pipeConn(t *testing.T, readBufferSize int, chunks ...string) (*Conn, net.Conn) {
+	t.Helper()
+
+	client, peer := net.Pipe()
+	t.Cleanup(func() {
+		_ = client.Close() //nolint:errcheck
+		_ = peer.Close()   //nolint:errcheck
+	})
+
+	go func() {
+		for _, chunk := range chunks {
+			if _, err := peer.Write([]byte(chunk)); err != nil {
+				return
+			}
+		}
+	}()
+
+	return New(client, readBufferSize, 0), peer
+}
+
+func TestConnPartialReads(t *testing.T) {
+	c, _ := pipeConn(t, 4, "ab", "c", "def")
+
+	// Peek waits for the data of multiple reads.
+	p, err := c.Peek(5)
+	require.NoError(t, err)
+	require.Equal(t, "abcde", string(p))
+
+	b, err := c.ReadByte()
+	require.NoError(t, err)
+	require.Equal(t, byte('a'), b)
+
+	p, err = c.ReadBinary(5)
+	require.NoError(t, err)
+	require.Equal(t, "bcdef", string(p))
+	require.Zero(t, c.Len())
+}
+
+func TestConnPeekAcrossBuffers(t *testing.T) {
+	c, _ := pipeConn(t, 4, "0123", "4567", "89")
+
+	first, err := c.Peek(3)
+	require.NoError(t, err)
+	require.Equal(t, "012", string(first))
+
+	// The buffer grows, previously peeked slices stay valid until Release.
+	all, err := c.Peek(10)
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", string(all))
+	require.Equal(t, "012", string(first))
+	require.Equal(t, 10, c.Len())
+}
+
+func TestConnReleaseAfterSkip(t *testing.T) {
+	c, peer := pipeConn(t, 4, "0123", "4567")
+
+	require.NoError(t, c.Skip(6))
+	require.Equal(t, 2, c.Len())
+	require.NoError(t, c.Release())
+	require.Equal(t, 2, c.Len())
+
+	p, err := c.Peek(2)
+	require.NoError(t, err)
+	require.Equal(t, "67", string(p))
+
+	// Releasing everything reuses the buffer for the next data.
+	require.NoError(t, c.Skip(2))
+	require.NoError(t, c.Release())
+	require.Zero(t, c.Len())
+
+	go func() {
+		_, _ = peer.Write([]byte("next")) //nolint:errcheck
+	}()
+
+	p, err = c.Peek(4)
+	require.NoError(t, err)
+	require.Equal(t, "next", string(p))
+}
+
+func TestConnReadBuffered(t *testing.T) {
+	c, _ := pipeConn(t, 8, "hello", " world")
+
+	_, err := c.Peek(3)
+	require.NoError(t, err)
+
+	// Read returns the buffered data first, then reads from the connection.
+	buf := make([]byte, 16)
+
+	n, err := c.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(buf[:n]))
+
+	n, err = c.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, " world", string(buf[:n]))
+}
+
+func TestConnEOF(t *testing.T) {
+	c, peer := pipeConn(t, 4, "abc")
+
+	p, err := c.Peek(3)
+	require.NoError(t, err)
+	require.Equal(t, "abc", string(p))
+
+	require.NoError(t, peer.Close())
+
+	_, err = c.Peek(4)
+	require.ErrorIs(t, err, io.EOF)
+
+	// The buffered data stays available.
+	require.NoError(t, c.Skip(3))
+	require.Zero(t, c.Len())
+}
+
+func TestConnToHertzError(t *testing.T) {
+	c := &Conn{}
+
+	require.ErrorIs(t, c.ToHertzError(syscall.EPIPE), herrors.ErrConnectionClosed)
+	require.ErrorIs(t, c.ToHertzError(syscall.ENOTCONN), herrors.ErrConnectionClosed)
+	require.ErrorIs(t, c.ToHertzError(os.ErrDeadlineExceeded), herrors.ErrTimeout)
+
+	err := errors.New("other")
+	require.Equal(t, err, c.ToHertzError(err))
+}
+
+func TestConnWriteBuffers(t *testing.T) {
+	client, peer := net.Pipe()
+	defer peer.Close() //nolint:errcheck
+
+	c := New(client, 4, 4)
+	defer c.Close() //nolint:errcheck
+
+	// Writes get buffered until Flush.
+	buf, err := c.Malloc(3)
+	require.NoError(t, err)
+	copy(buf, "abc")
+
+	_, err = c.WriteBinary([]byte("d"))
+	require.NoError(t, err)
+
+	// Larger writes than the buffer don't get copied.
+	_, err = c.WriteBinary([]byte("efghij"))
+	require.NoError(t, err)
+
+	read := make(chan string, 1)
+
+	go func() {
+		b, _ := io.ReadAll(io.LimitReader(peer, 10)) //nolint:errcheck
+		read <- string(b)
+	}()
+
+	require.NoError(t, c.Flush())
+	require.Equal(t, "abcdefghij", <-read)
+}
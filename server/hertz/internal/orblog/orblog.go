@@ -8,6 +8,7 @@ import (
 
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"github.com/go-orb/go-orb/log"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // NewLogger creates a new hertz/hlog->go-orb/log wrapper.
@@ -32,7 +33,17 @@ func (l *Logger) logf(level hlog.Level, format string, kvs ...any) {
 
 func (l *Logger) ctxLogf(ctx context.Context, level hlog.Level, format string, v ...any) {
 	lvl := hLevelToSLevel(level)
-	l.l.Log(ctx, lvl, fmt.Sprintf(format, v...))
+	l.l.Log(ctx, lvl, fmt.Sprintf(format, v...), TraceAttrs(ctx)...)
+}
+
+// TraceAttrs returns the trace and span ID of the span in ctx as log attributes.
+func TraceAttrs(ctx context.Context) []any {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	return []any{"trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String()}
 }
 
 // Trace logs.
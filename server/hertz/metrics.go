@@ -0,0 +1,173 @@
+package hertz
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// metricsLabelsKey is the key of the *requestLabels in the request context.
+const metricsLabelsKey = "orb.hertz.metricsLabels"
+
+// sizeBuckets are the buckets of the size histograms, from 64 B to 16 MiB.
+var sizeBuckets = prometheus.ExponentialBuckets(64, 4, 10) //nolint:gochecknoglobals
+
+// serverMetrics are the metrics of an entrypoint.
+type serverMetrics struct {
+	registry *prometheus.Registry
+
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	inFlight     *prometheus.GaugeVec
+	requestSize  *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+}
+
+func newServerMetrics() *serverMetrics {
+	labels := []string{"service", "method", "code", "content_type"}
+
+	m := &serverMetrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hertz_server_requests_total",
+			Help: "Number of requests handled by the hertz entrypoint.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "hertz_server_request_duration_seconds",
+			Help:    "Time it took to handle the requests.",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "hertz_server_requests_in_flight",
+			Help: "Number of requests being handled.",
+		}, []string{"service", "method"}),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "hertz_server_request_size_bytes",
+			Help:    "Size of the request bodies as sent by the clients.",
+			Buckets: sizeBuckets,
+		}, labels),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "hertz_server_response_size_bytes",
+			Help:    "Size of the response bodies as sent to the clients.",
+			Buckets: sizeBuckets,
+		}, labels),
+	}
+
+	m.registry.MustRegister(m.requests, m.duration, m.inFlight, m.requestSize, m.responseSize)
+
+	return m
+}
+
+// Metrics returns the registry with the metrics of the entrypoint, it's private
+// to the entrypoint. Registration functions can add their own collectors to it,
+// they get served with the others when Config.Metrics is enabled.
+func (s *Server) Metrics() *prometheus.Registry {
+	return s.metrics.registry
+}
+
+// requestLabels are the service and method labels of a request.
+type requestLabels struct {
+	service string
+	method  string
+}
+
+// observeRequest is a hertz middleware which collects the metrics of all routes.
+//
+// Requests get labeled with the name of the service and the route, handlers
+// of NewGRPCHandler and NewStreamHandler replace them with their service and method.
+func (s *Server) observeRequest(ctx context.Context, apCtx *app.RequestContext) {
+	start := time.Now()
+
+	labels := &requestLabels{service: s.serviceName, method: apCtx.FullPath()}
+	apCtx.Set(metricsLabelsKey, labels)
+
+	s.metrics.inFlight.WithLabelValues(labels.service, labels.method).Inc()
+
+	defer func() {
+		s.metrics.inFlight.WithLabelValues(labels.service, labels.method).Dec()
+	}()
+
+	apCtx.Next(ctx)
+
+	values := []string{
+		labels.service,
+		labels.method,
+		strconv.Itoa(apCtx.Response.StatusCode()),
+		utils.FilterContentType(string(apCtx.Response.Header.ContentType())),
+	}
+
+	s.metrics.requests.WithLabelValues(values...).Inc()
+	s.metrics.duration.WithLabelValues(values...).Observe(time.Since(start).Seconds())
+	s.metrics.requestSize.WithLabelValues(values...).Observe(float64(requestSize(apCtx)))
+
+	// The size of streamed responses isn't known.
+	if !apCtx.Response.IsBodyStream() && apCtx.Response.GetHijackWriter() == nil {
+		s.metrics.responseSize.WithLabelValues(values...).Observe(float64(len(apCtx.Response.Body())))
+	}
+}
+
+// setRequestLabels replaces the service and method labels of the request.
+func (s *Server) setRequestLabels(apCtx *app.RequestContext, service, method string) {
+	v, _ := apCtx.Get(metricsLabelsKey)
+
+	labels, ok := v.(*requestLabels)
+	if !ok {
+		return
+	}
+
+	s.metrics.inFlight.WithLabelValues(labels.service, labels.method).Dec()
+
+	labels.service = service
+	labels.method = method
+
+	s.metrics.inFlight.WithLabelValues(labels.service, labels.method).Inc()
+}
+
+// requestSize returns the size of the request body as sent by the client,
+// it's 0 for streamed bodies without a Content-Length.
+func requestSize(apCtx *app.RequestContext) int {
+	if n := apCtx.Request.Header.ContentLength(); n >= 0 {
+		return n
+	}
+
+	if apCtx.Request.IsBodyStream() {
+		return 0
+	}
+
+	return len(apCtx.Request.Body())
+}
+
+// serveMetrics serves the metrics in the format negotiated with the Accept header.
+func (s *Server) serveMetrics(c context.Context, ctx *app.RequestContext) {
+	mfs, err := s.metrics.registry.Gather()
+	if err != nil {
+		s.logError(c, "failed to gather metrics", err)
+		WriteError(ctx, orberrors.ErrInternalServerError.Wrap(err))
+		return
+	}
+
+	format := expfmt.Negotiate(http.Header{"Accept": []string{string(ctx.GetHeader(consts.HeaderAccept))}})
+
+	buf := &bytes.Buffer{}
+	enc := expfmt.NewEncoder(buf, format)
+
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			s.logError(c, "failed to encode metrics", err)
+			WriteError(ctx, orberrors.ErrInternalServerError.Wrap(err))
+			return
+		}
+	}
+
+	ctx.Response.Header.Set("Cache-Control", "no-store")
+	ctx.Data(consts.StatusOK, string(format), buf.Bytes())
+}
@@ -0,0 +1,39 @@
+package hertz
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	srv := setupServer(t, WithInsecure(), WithMetrics(), withCodecEcho(), withPing())
+
+	for range 2 {
+		resp, _ := doRequest(t, srv, http.MethodPost, codecEndpoint, []byte(`{"text":"hello"}`),
+			map[string]string{"Content-Type": consts.MIMEApplicationJSON})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	resp, _ := doRequest(t, srv, http.MethodGet, pingPath, nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body := doRequest(t, srv, http.MethodGet, DefaultMetricsPath, nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	metrics := string(body)
+
+	for _, line := range []string{
+		`hertz_server_requests_total{code="200",content_type="application/json",method="Echo",service="test.Codec"} 2`,
+		`hertz_server_requests_total{code="200",content_type="text/plain",method="/ping",service="test.hertz"} 1`,
+		`hertz_server_request_duration_seconds_count{code="200",content_type="application/json",method="Echo",service="test.Codec"} 2`,
+		`hertz_server_request_size_bytes_count{code="200",content_type="application/json",method="Echo",service="test.Codec"} 2`,
+		`hertz_server_response_size_bytes_count{code="200",content_type="application/json",method="Echo",service="test.Codec"} 2`,
+		`hertz_server_requests_in_flight{method="Echo",service="test.Codec"} 0`,
+		`hertz_server_requests_in_flight{method="/metrics",service="test.hertz"} 1`,
+	} {
+		require.Contains(t, metrics, line)
+	}
+}
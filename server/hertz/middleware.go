@@ -0,0 +1,71 @@
+package hertz
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/go-orb/go-orb/server"
+)
+
+// WithRouteMiddlewares adds middlewares to a single route of NewGRPCHandler
+// or NewStreamHandler, they run inside the entrypoint middlewares.
+func WithRouteMiddlewares(mws ...server.Middleware) HandlerOption {
+	return func(o *handlerOptions) {
+		o.middlewares = append(o.middlewares, mws...)
+	}
+}
+
+// chainMiddlewares wraps h with the middlewares in onion order, the first
+// middleware is the outermost one: it sees the request first and the response last.
+//
+// For NewGRPCHandler and NewStreamHandler the order is the entrypoint middlewares
+// in the order they have been configured, followed by the route middlewares of
+// WithRouteMiddlewares.
+func chainMiddlewares(h server.MiddlewareCallHandler, mws []server.Middleware) server.MiddlewareCallHandler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i].Call(h)
+	}
+
+	return h
+}
+
+// NewHertzMiddleware runs orb middlewares as a hertz middleware, so they
+// also apply to routes registered directly on the Router.
+//
+// The middlewares get the *app.RequestContext as request and the incoming
+// metadata with the route as method, calling the next handler runs the rest
+// of the hertz chain, its response is the *protocol.Response. An error of a
+// middleware gets written with WriteError and aborts the chain, as does a
+// middleware which doesn't call the next handler.
+//
+// Like for NewGRPCHandler the middlewares run in onion order.
+func NewHertzMiddleware(mws ...server.Middleware) app.HandlerFunc {
+	return func(ctx context.Context, apCtx *app.RequestContext) {
+		ctx, outMd := incomingMetadata(ctx, apCtx, "", apCtx.FullPath())
+
+		called := false
+
+		h := chainMiddlewares(func(ctx context.Context, _ any) (any, error) {
+			called = true
+
+			apCtx.Next(ctx)
+
+			return &apCtx.Response, nil
+		}, mws)
+
+		if _, err := h(ctx, apCtx); err != nil {
+			WriteError(apCtx, err)
+			apCtx.Abort()
+
+			return
+		}
+
+		if !called {
+			apCtx.Abort()
+		}
+
+		for k, v := range outMd {
+			apCtx.Header(k, v)
+		}
+	}
+}
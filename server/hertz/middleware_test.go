@@ -0,0 +1,242 @@
+package hertz
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/server"
+	"github.com/go-orb/go-orb/util/metadata"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+const middlewareStreamEndpoint = "/test.Middleware/Stream"
+
+// callRecorder records the order middlewares get called in.
+type callRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *callRecorder) add(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, call)
+}
+
+func (r *callRecorder) reset() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	calls := r.calls
+	r.calls = nil
+
+	return calls
+}
+
+// recordMiddleware records when the request enters and the response leaves it.
+type recordMiddleware struct {
+	name     string
+	recorder *callRecorder
+}
+
+func (m *recordMiddleware) Start(_ context.Context) error { return nil }
+func (m *recordMiddleware) Stop(_ context.Context) error  { return nil }
+func (m *recordMiddleware) Type() string                  { return "middleware" }
+func (m *recordMiddleware) String() string                { return m.name }
+
+func (m *recordMiddleware) Call(next server.MiddlewareCallHandler) server.MiddlewareCallHandler {
+	return func(ctx context.Context, req any) (any, error) {
+		m.recorder.add(m.name + " in")
+
+		out, err := next(ctx, req)
+
+		m.recorder.add(m.name + " out")
+
+		return out, err
+	}
+}
+
+// authMiddleware rejects requests without the "authorization" metadata.
+type authMiddleware struct{}
+
+func (m *authMiddleware) Start(_ context.Context) error { return nil }
+func (m *authMiddleware) Stop(_ context.Context) error  { return nil }
+func (m *authMiddleware) Type() string                  { return "middleware" }
+func (m *authMiddleware) String() string                { return "auth" }
+
+func (m *authMiddleware) Call(next server.MiddlewareCallHandler) server.MiddlewareCallHandler {
+	return func(ctx context.Context, req any) (any, error) {
+		md, _ := metadata.Incoming(ctx)
+		if md["authorization"] == "" {
+			return nil, orberrors.ErrUnauthorized
+		}
+
+		return next(ctx, req)
+	}
+}
+
+// echoCodecStream echos all messages back.
+func echoCodecStream(stream ServerStream[codecMsg, codecMsg]) error {
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func setupMiddlewareServer(t *testing.T, recorder *callRecorder) *Server {
+	t.Helper()
+
+	mw := func(name string) server.Middleware {
+		return &recordMiddleware{name: name, recorder: recorder}
+	}
+
+	return setupServer(t, WithInsecure(), WithAllowH2C(), server.WithEntrypointMiddlewares(mw("first"), mw("second")),
+		withRoutes(func(s *Server) {
+			s.Router().POST("/test.Middleware/Echo", NewGRPCHandler(s, echoCodecMsg, "test.Middleware", "Echo"))
+			s.Router().POST("/test.Middleware/Route", NewGRPCHandler(s, echoCodecMsg, "test.Middleware", "Route",
+				WithRouteMiddlewares(mw("route"))))
+			s.Router().POST(middlewareStreamEndpoint, NewStreamHandler(s, echoCodecStream, "test.Middleware", "Stream",
+				WithRouteMiddlewares(mw("route"), &authMiddleware{})))
+
+			raw := s.Router().Group("/raw", NewHertzMiddleware(mw("hertz"), &authMiddleware{}))
+			raw.GET("/ping", func(_ context.Context, ctx *app.RequestContext) {
+				recorder.add("raw")
+				ctx.String(consts.StatusOK, "pong")
+			})
+		}))
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	recorder := &callRecorder{}
+	srv := setupMiddlewareServer(t, recorder)
+
+	request := func(path string) {
+		resp, body := doRequest(t, srv, http.MethodPost, path, []byte(`{"text":"hello"}`),
+			map[string]string{"Content-Type": consts.MIMEApplicationJSON})
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		require.JSONEq(t, `{"text":"hello"}`, string(body))
+	}
+
+	request("/test.Middleware/Echo")
+	require.Equal(t, []string{"first in", "second in", "second out", "first out"}, recorder.reset())
+
+	request("/test.Middleware/Route")
+	require.Equal(t, []string{"first in", "second in", "route in", "route out", "second out", "first out"},
+		recorder.reset())
+}
+
+func TestHertzMiddleware(t *testing.T) {
+	recorder := &callRecorder{}
+	srv := setupMiddlewareServer(t, recorder)
+
+	resp, body := doRequest(t, srv, http.MethodGet, "/raw/ping", nil, map[string]string{"Authorization": "token"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "pong", string(body))
+	require.Equal(t, []string{"hertz in", "raw", "hertz out"}, recorder.reset())
+
+	resp, _ = doRequest(t, srv, http.MethodGet, "/raw/ping", nil, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	require.Equal(t, []string{"hertz in", "hertz out"}, recorder.reset())
+}
+
+// h2cClient returns a HTTP/2 client without TLS.
+func h2cClient() *http.Client {
+	return &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+}
+
+// streamRequest sends the messages as one stream, it returns the received messages and the end status.
+func streamRequest(t *testing.T, srv *Server, header map[string]string, texts ...string) ([]string, *errorBody) {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+
+	for _, text := range texts {
+		payload, err := json.Marshal(&codecMsg{Text: text})
+		require.NoError(t, err)
+		require.NoError(t, writeFrame(body, 0, payload))
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost,
+		serverURL(srv, middlewareStreamEndpoint), body)
+	require.NoError(t, err)
+
+	req.Header.Set("Content-Type", consts.MIMEApplicationJSON)
+
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h2cClient().Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var received []string
+
+	for {
+		flags, payload, err := readFrame(resp.Body, 0)
+		require.NoError(t, err)
+
+		if flags&frameFlagEnd != 0 {
+			status := &errorBody{}
+			require.NoError(t, json.Unmarshal(payload, status))
+
+			return received, status
+		}
+
+		msg := &codecMsg{}
+		require.NoError(t, json.Unmarshal(payload, msg))
+
+		received = append(received, msg.Text)
+	}
+}
+
+func TestStreamMiddlewares(t *testing.T) {
+	recorder := &callRecorder{}
+	srv := setupMiddlewareServer(t, recorder)
+
+	// The middlewares run once for the whole stream.
+	received, status := streamRequest(t, srv, map[string]string{"Authorization": "token"}, "a", "b")
+	require.Equal(t, []string{"a", "b"}, received)
+	require.Equal(t, consts.StatusOK, status.Code)
+	require.Equal(t, []string{"first in", "second in", "route in", "route out", "second out", "first out"},
+		recorder.reset())
+
+	// A middleware error ends the stream.
+	received, status = streamRequest(t, srv, nil, "a")
+	require.Empty(t, received)
+	require.Equal(t, orberrors.ErrUnauthorized.Code, status.Code)
+	require.Equal(t, []string{"first in", "second in", "route in", "route out", "second out", "first out"},
+		recorder.reset())
+}
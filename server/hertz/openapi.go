@@ -0,0 +1,359 @@
+package hertz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// openAPIVersion is the version of the OpenAPI specification of the document.
+const openAPIVersion = "3.0.3"
+
+// operation describes a registered handler for the OpenAPI document.
+type operation struct {
+	service string
+	method  string
+
+	// in and out are the request and response types.
+	in  reflect.Type
+	out reflect.Type
+
+	stream bool
+
+	// httpMethod and route are set for RESTful routes, other operations
+	// get matched with the route "/<service>/<method>".
+	httpMethod string
+	route      string
+	vars       []pathVar
+	body       string
+}
+
+// addOperation records a registered handler for the OpenAPI document.
+func (s *Server) addOperation(op *operation) {
+	s.operations = append(s.operations, op)
+}
+
+// findOperation returns the operation of a route, it's nil for routes
+// which haven't been registered by the handler constructors.
+func (s *Server) findOperation(r route.RouteInfo) *operation {
+	for _, op := range s.operations {
+		if op.route != "" && op.route == r.Path && op.httpMethod == r.Method {
+			return op
+		}
+	}
+
+	for _, op := range s.operations {
+		if op.route == "" && "/"+op.service+"/"+op.method == r.Path {
+			return op
+		}
+	}
+
+	return nil
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*schema `json:"schemas,omitempty"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []*openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *schema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *schema `json:"schema,omitempty"`
+}
+
+// openAPIDocument builds the OpenAPI document of all routes registered on the router.
+func (s *Server) openAPIDocument() *openAPIDocument {
+	doc := &openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info:    openAPIInfo{Title: s.serviceName, Version: s.serviceVersion},
+		Paths:   map[string]map[string]*openAPIOperation{},
+	}
+
+	gen := newSchemaGenerator()
+	ids := map[string]int{}
+
+	routes := s.Router().Routes()
+	slices.SortFunc(routes, func(a, b route.RouteInfo) int {
+		return strings.Compare(a.Path+" "+a.Method, b.Path+" "+b.Method)
+	})
+
+	for _, r := range routes {
+		if r.Path == s.config.OpenAPIPath || (s.config.SwaggerUI && r.Path == s.config.SwaggerUIPath) {
+			continue
+		}
+
+		op := s.findOperation(r)
+
+		var vars []pathVar
+		if op != nil {
+			vars = op.vars
+		}
+
+		path, params := openAPIPath(r.Path, vars)
+
+		o := &openAPIOperation{
+			Responses: map[string]*openAPIResponse{
+				"default": {Description: "Error", Content: errorContent()},
+			},
+		}
+
+		if op != nil {
+			s.describeOperation(gen, o, op, r.Method, params)
+
+			id := op.service + "_" + op.method
+			if ids[id]++; ids[id] > 1 {
+				id += "_" + strconv.Itoa(ids[id])
+			}
+
+			o.OperationID = id
+		} else {
+			o.Responses["200"] = &openAPIResponse{Description: "OK"}
+
+			for _, p := range params {
+				o.Parameters = append(o.Parameters, &openAPIParameter{
+					Name: p, In: "path", Required: true, Schema: &schema{Type: "string"},
+				})
+			}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*openAPIOperation{}
+		}
+
+		doc.Paths[path][strings.ToLower(r.Method)] = o
+	}
+
+	doc.Components.Schemas = gen.schemas
+
+	return doc
+}
+
+// describeOperation fills the parameters, request and response of o.
+func (s *Server) describeOperation(
+	gen *schemaGenerator,
+	o *openAPIOperation,
+	op *operation,
+	method string,
+	params []string,
+) {
+	o.Summary = op.service + "." + op.method
+	o.Tags = []string{op.service}
+
+	inSchema, outSchema := gen.forType(op.in), gen.forType(op.out)
+
+	inTypes := supportedTypes(codecsByMime(reflect.New(op.in).Interface()))
+	outTypes := supportedTypes(codecsByMime(reflect.New(op.out).Interface()))
+
+	if op.stream {
+		o.Description = "Bidirectional stream over HTTP/2. Both bodies are sequences of frames: " +
+			"a 1 byte flag, the 4 byte big endian length and the encoded message. " +
+			"The last response frame is flagged with 0x80 and contains the status as " + errorBodySchema + "."
+	}
+
+	o.Responses["200"] = &openAPIResponse{Description: "OK", Content: mediaTypes(outTypes, outSchema)}
+
+	// Path parameters by their field paths, other parameters are strings.
+	var md protoreflect.MessageDescriptor
+	if msg, ok := reflect.New(op.in).Interface().(proto.Message); ok {
+		md = msg.ProtoReflect().Descriptor()
+	}
+
+	for _, p := range params {
+		ps := &schema{Type: "string"}
+
+		if md != nil {
+			if fd, err := lookupField(md, p); err == nil {
+				ps = gen.forValue(fd)
+			}
+		}
+
+		o.Parameters = append(o.Parameters, &openAPIParameter{Name: p, In: "path", Required: true, Schema: ps})
+	}
+
+	switch {
+	case op.route == "":
+		// RPC routes take the message as body, GET binds it from the query.
+		if method != consts.MethodGet {
+			o.RequestBody = &openAPIRequestBody{Required: true, Content: mediaTypes(inTypes, inSchema)}
+		}
+	case op.body == "*":
+		o.RequestBody = &openAPIRequestBody{Content: mediaTypes(inTypes, inSchema)}
+	default:
+		if op.body != "" && md != nil {
+			if fd, err := lookupField(md, op.body); err == nil {
+				o.RequestBody = &openAPIRequestBody{Content: mediaTypes(inTypes, gen.forValue(fd))}
+			}
+		}
+
+		if md != nil {
+			o.Parameters = append(o.Parameters, queryParameters(gen, md, op)...)
+		}
+	}
+}
+
+// queryParameters returns the top level scalar fields of a RESTful route which
+// aren't bound by the path or the body.
+func queryParameters(gen *schemaGenerator, md protoreflect.MessageDescriptor, op *operation) []*openAPIParameter {
+	params := []*openAPIParameter{}
+
+	for i := range md.Fields().Len() {
+		fd := md.Fields().Get(i)
+		if fd.IsMap() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+			continue
+		}
+
+		name := string(fd.Name())
+		if name == op.body || slices.ContainsFunc(op.vars, func(v pathVar) bool {
+			return v.field == name || v.field == fd.JSONName()
+		}) {
+			continue
+		}
+
+		params = append(params, &openAPIParameter{Name: name, In: "query", Schema: gen.forField(fd)})
+	}
+
+	return params
+}
+
+// openAPIPath converts a hertz route like "/v1/users/:p0" into the OpenAPI
+// path "/v1/users/{id}", it returns the names of the parameters.
+func openAPIPath(path string, vars []pathVar) (string, []string) {
+	segments := strings.Split(path, "/")
+	params := []string{}
+
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, ":") && !strings.HasPrefix(seg, "*") {
+			continue
+		}
+
+		name := seg[1:]
+
+		for _, v := range vars {
+			if v.param == name {
+				name = v.field
+			}
+		}
+
+		segments[i] = "{" + name + "}"
+		params = append(params, name)
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+// mediaTypes returns the content of a body for the content types, JSON if there are none.
+func mediaTypes(contentTypes []string, s *schema) map[string]*openAPIMediaType {
+	if len(contentTypes) == 0 {
+		contentTypes = []string{consts.MIMEApplicationJSON}
+	}
+
+	content := map[string]*openAPIMediaType{}
+	for _, ct := range contentTypes {
+		content[ct] = &openAPIMediaType{Schema: s}
+	}
+
+	return content
+}
+
+// errorContent returns the content of error responses.
+func errorContent() map[string]*openAPIMediaType {
+	return mediaTypes(supportedTypes(codecsByMime(nil)), schemaRef(errorBodySchema))
+}
+
+// serveOpenAPI serves the OpenAPI document.
+func (s *Server) serveOpenAPI(c context.Context, ctx *app.RequestContext) {
+	data, err := json.Marshal(s.openAPIDocument())
+	if err != nil {
+		s.logError(c, "failed to encode the OpenAPI document", err)
+		WriteError(ctx, orberrors.ErrInternalServerError.Wrap(err))
+		return
+	}
+
+	ctx.Data(consts.StatusOK, consts.MIMEApplicationJSONUTF8, data)
+}
+
+// swaggerUITemplate loads Swagger UI from a CDN.
+var swaggerUITemplate = template.Must(template.New("swagger").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>{{ .Title }}</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: {{ .URL }}, dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`)) //nolint:gochecknoglobals
+
+// serveSwaggerUI serves a Swagger UI page for the OpenAPI document.
+func (s *Server) serveSwaggerUI(c context.Context, ctx *app.RequestContext) {
+	buf := &strings.Builder{}
+
+	err := swaggerUITemplate.Execute(buf, map[string]string{
+		"Title": fmt.Sprintf("%s %s", s.serviceName, s.serviceVersion),
+		"URL":   s.config.OpenAPIPath,
+	})
+	if err != nil {
+		s.logError(c, "failed to render the swagger UI", err)
+		WriteError(ctx, orberrors.ErrInternalServerError.Wrap(err))
+		return
+	}
+
+	ctx.Data(consts.StatusOK, consts.MIMETextHtml+"; charset=utf-8", []byte(buf.String()))
+}
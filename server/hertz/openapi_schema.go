@@ -0,0 +1,286 @@
+package hertz
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// errorBodySchema is the name of the schema of error responses.
+const errorBodySchema = "ErrorBody"
+
+// schema is an OpenAPI 3.0 schema object.
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+func schemaRef(name string) *schema {
+	return &schema{Ref: "#/components/schemas/" + name}
+}
+
+// schemaGenerator creates the schemas of Go and proto types, named types
+// end up in schemas and get referenced.
+type schemaGenerator struct {
+	schemas map[string]*schema
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	g := &schemaGenerator{schemas: map[string]*schema{}}
+
+	g.schemas[errorBodySchema] = &schema{
+		Type:        "object",
+		Description: "The error of a failed request, wrapped is the error it wraps.",
+		Properties: map[string]*schema{
+			"code":    {Type: "integer", Format: "int32"},
+			"message": {Type: "string"},
+			"wrapped": schemaRef(errorBodySchema),
+		},
+	}
+
+	return g
+}
+
+//nolint:gochecknoglobals
+var (
+	protoMessageType = reflect.TypeFor[proto.Message]()
+	timeType         = reflect.TypeFor[time.Time]()
+)
+
+// forType returns the schema of a Go type, proto messages get
+// described the way protojson encodes them.
+//
+//nolint:cyclop
+func (g *schemaGenerator) forType(t reflect.Type) *schema {
+	if t.Implements(protoMessageType) {
+		msg, _ := reflect.Zero(t).Interface().(proto.Message) //nolint:errcheck
+		return g.forMessage(msg.ProtoReflect().Descriptor())
+	}
+
+	if t.Kind() == reflect.Pointer {
+		return g.forType(t.Elem())
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &schema{Type: "string", Format: "byte"}
+		}
+
+		return &schema{Type: "array", Items: g.forType(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: g.forType(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return &schema{Type: "string", Format: "date-time"}
+		}
+
+		return g.forStruct(t)
+	default:
+		// Interfaces and everything else can be anything.
+		return &schema{}
+	}
+}
+
+// forStruct references the schema of a struct, anonymous structs get inlined.
+func (g *schemaGenerator) forStruct(t reflect.Type) *schema {
+	if t.Name() == "" {
+		return g.structSchema(t)
+	}
+
+	name := t.Name()
+	if pkg := t.PkgPath(); pkg != "" {
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+
+	if _, ok := g.schemas[name]; !ok {
+		// Reserve the name first, the struct may reference itself.
+		g.schemas[name] = &schema{}
+		g.schemas[name] = g.structSchema(t)
+	}
+
+	return schemaRef(name)
+}
+
+// structSchema describes the exported fields of a struct by their JSON names.
+func (g *schemaGenerator) structSchema(t reflect.Type) *schema {
+	s := &schema{Type: "object", Properties: map[string]*schema{}}
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// Embedded structs without a name get flattened like encoding/json does.
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				for k, v := range g.structSchema(ft).Properties {
+					s.Properties[k] = v
+				}
+
+				continue
+			}
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = g.forType(f.Type)
+	}
+
+	return s
+}
+
+// forMessage returns the schema of a proto message in its protojson form.
+func (g *schemaGenerator) forMessage(md protoreflect.MessageDescriptor) *schema {
+	if s := wellKnownSchema(md.FullName()); s != nil {
+		return s
+	}
+
+	name := string(md.FullName())
+
+	if _, ok := g.schemas[name]; !ok {
+		s := &schema{Type: "object", Properties: map[string]*schema{}}
+
+		// Reserve the name first, the message may reference itself.
+		g.schemas[name] = s
+
+		for i := range md.Fields().Len() {
+			fd := md.Fields().Get(i)
+			s.Properties[fd.JSONName()] = g.forField(fd)
+		}
+	}
+
+	return schemaRef(name)
+}
+
+// forField returns the schema of a proto field.
+func (g *schemaGenerator) forField(fd protoreflect.FieldDescriptor) *schema {
+	switch {
+	case fd.IsMap():
+		return &schema{Type: "object", AdditionalProperties: g.forValue(fd.MapValue())}
+	case fd.IsList():
+		return &schema{Type: "array", Items: g.forValue(fd)}
+	default:
+		return g.forValue(fd)
+	}
+}
+
+// forValue returns the schema of a single value of a proto field.
+//
+//nolint:cyclop
+func (g *schemaGenerator) forValue(fd protoreflect.FieldDescriptor) *schema {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return &schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &schema{Type: "integer", Format: "int64"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// protojson encodes 64 bit integers as strings.
+		return &schema{Type: "string", Format: "int64"}
+	case protoreflect.FloatKind:
+		return &schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &schema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &schema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		s := &schema{Type: "string", Enum: make([]string, 0, values.Len())}
+
+		for i := range values.Len() {
+			s.Enum = append(s.Enum, string(values.Get(i).Name()))
+		}
+
+		return s
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return g.forMessage(fd.Message())
+	}
+
+	return &schema{}
+}
+
+// wellKnownSchema returns the schema of the well known types with a special JSON mapping.
+//
+//nolint:cyclop
+func wellKnownSchema(name protoreflect.FullName) *schema {
+	switch name {
+	case "google.protobuf.Timestamp":
+		return &schema{Type: "string", Format: "date-time"}
+	case "google.protobuf.Duration":
+		return &schema{Type: "string", Description: "A duration in seconds with the suffix 's', e.g. \"1.5s\"."}
+	case "google.protobuf.FieldMask":
+		return &schema{Type: "string", Description: "Comma separated field paths."}
+	case "google.protobuf.Struct":
+		return &schema{Type: "object", AdditionalProperties: &schema{}}
+	case "google.protobuf.ListValue":
+		return &schema{Type: "array", Items: &schema{}}
+	case "google.protobuf.Value":
+		return &schema{}
+	case "google.protobuf.Empty":
+		return &schema{Type: "object"}
+	case "google.protobuf.Any":
+		return &schema{
+			Type:                 "object",
+			Properties:           map[string]*schema{"@type": {Type: "string"}},
+			AdditionalProperties: &schema{},
+		}
+	case "google.protobuf.BoolValue":
+		return &schema{Type: "boolean", Nullable: true}
+	case "google.protobuf.StringValue":
+		return &schema{Type: "string", Nullable: true}
+	case "google.protobuf.BytesValue":
+		return &schema{Type: "string", Format: "byte", Nullable: true}
+	case "google.protobuf.Int32Value":
+		return &schema{Type: "integer", Format: "int32", Nullable: true}
+	case "google.protobuf.UInt32Value":
+		return &schema{Type: "integer", Format: "int64", Nullable: true}
+	case "google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return &schema{Type: "string", Format: "int64", Nullable: true}
+	case "google.protobuf.FloatValue":
+		return &schema{Type: "number", Format: "float", Nullable: true}
+	case "google.protobuf.DoubleValue":
+		return &schema{Type: "number", Format: "double", Nullable: true}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package hertz
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/log"
+	"github.com/go-orb/go-orb/registry"
+	"github.com/stretchr/testify/require"
+)
+
+// openAPIStreamEndpoint is the route of openAPIStream.
+const openAPIStreamEndpoint = "/test.Streams/Echo"
+
+// openAPIDoc is the part of the OpenAPI document the tests look at.
+type openAPIDoc struct {
+	OpenAPI string `json:"openapi"`
+	Info    struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths map[string]map[string]struct {
+		OperationID string `json:"operationId"`
+		Parameters  []struct {
+			Name   string         `json:"name"`
+			In     string         `json:"in"`
+			Schema map[string]any `json:"schema"`
+		} `json:"parameters"`
+		RequestBody *struct {
+			Content map[string]struct {
+				Schema map[string]any `json:"schema"`
+			} `json:"content"`
+		} `json:"requestBody"`
+		Responses map[string]struct {
+			Content map[string]struct {
+				Schema map[string]any `json:"schema"`
+			} `json:"content"`
+		} `json:"responses"`
+	} `json:"paths"`
+	Components struct {
+		Schemas map[string]map[string]any `json:"schemas"`
+	} `json:"components"`
+}
+
+// openAPIStream is a stream handler which returns immediately.
+func openAPIStream(_ ServerStream[codecMsg, codecMsg]) error {
+	return nil
+}
+
+func TestOpenAPI(t *testing.T) {
+	srv := setupServer(t,
+		WithInsecure(),
+		WithSwaggerUI(),
+		withCodecEcho(),
+		withRoutes(func(s *Server) {
+			s.Router().POST(openAPIStreamEndpoint, NewStreamHandler(s, openAPIStream, "test.Streams", "Echo"))
+			s.Router().GET("/raw/:id", func(_ context.Context, ctx *app.RequestContext) {
+				ctx.String(consts.StatusOK, "raw")
+			})
+		}),
+		WithHandlers(NewServiceDescRegistration(fieldServiceDesc(t, restRules()), fieldService{})),
+	)
+
+	resp, body := doRequest(t, srv, http.MethodGet, DefaultOpenAPIPath, nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	doc := &openAPIDoc{}
+	require.NoError(t, json.Unmarshal(body, doc))
+
+	require.Equal(t, "3.0.3", doc.OpenAPI)
+	require.Equal(t, "test.hertz", doc.Info.Title)
+	require.Equal(t, "v1.0.0", doc.Info.Version)
+	require.NotContains(t, doc.Paths, DefaultOpenAPIPath)
+	require.NotContains(t, doc.Paths, DefaultSwaggerUIPath)
+
+	// NewGRPCHandler with a Go type.
+	echo := doc.Paths[codecEndpoint]["post"]
+	require.Equal(t, "test.Codec_Echo", echo.OperationID)
+	require.NotNil(t, echo.RequestBody)
+	require.Equal(t, "#/components/schemas/hertz.codecMsg", echo.RequestBody.Content["application/json"].Schema["$ref"])
+	require.Equal(t, "#/components/schemas/ErrorBody", echo.Responses["default"].Content["application/json"].Schema["$ref"])
+	require.Contains(t, doc.Components.Schemas["hertz.codecMsg"]["properties"], "text")
+	require.Contains(t, doc.Components.Schemas, "ErrorBody")
+
+	// NewStreamHandler.
+	require.Equal(t, "test.Streams_Echo", doc.Paths[openAPIStreamEndpoint]["post"].OperationID)
+
+	// RESTful routes of the service descriptor.
+	get := doc.Paths["/v1/fields/{name}"]["get"]
+	require.Equal(t, "test.rest.FieldService_GetField", get.OperationID)
+	require.Nil(t, get.RequestBody)
+
+	params := map[string]string{}
+	for _, p := range get.Parameters {
+		params[p.Name] = p.In
+	}
+
+	require.Equal(t, "path", params["name"])
+	require.Equal(t, "query", params["number"])
+	require.Contains(t, doc.Paths["/v1/fields/{name}"], "put")
+	require.Contains(t, doc.Paths["/v1/fields/{name}"], "delete")
+	require.Contains(t, doc.Paths, "/v1/files/{type_url}")
+	require.Contains(t, doc.Paths, "/test.rest.FieldService/GetField")
+
+	patch := doc.Paths["/v1/types/{name}"]["patch"]
+	require.NotNil(t, patch.RequestBody)
+	require.Equal(t, "#/components/schemas/google.protobuf.SourceContext",
+		patch.RequestBody.Content["application/json"].Schema["$ref"])
+	require.Contains(t, doc.Components.Schemas["google.protobuf.Field"]["properties"], "jsonName")
+
+	// Routes without metadata.
+	raw := doc.Paths["/raw/{id}"]["get"]
+	require.Len(t, raw.Parameters, 1)
+	require.Equal(t, "id", raw.Parameters[0].Name)
+
+	resp, body = doRequest(t, srv, http.MethodGet, DefaultSwaggerUIPath, nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, string(body), "swagger-ui")
+	require.Contains(t, string(body), "openapi.json")
+}
+
+func TestOpenAPIConfig(t *testing.T) {
+	cfg := NewConfig(WithInsecure())
+	cfg.SwaggerUI = true
+
+	_, err := New("test.openapi", "v1.0.0", "hertzhttp", cfg, log.Logger{}, registry.Type{})
+	require.ErrorIs(t, err, ErrInvalidConfig)
+}
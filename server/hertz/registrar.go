@@ -0,0 +1,187 @@
+package hertz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	orbserver "github.com/go-orb/go-orb/server"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Registration errors.
+var (
+	ErrNoMethods       = errors.New("no method with the signature func(context.Context, *In) (*Out, error) found")
+	ErrMissingMethod   = errors.New("method of the service descriptor not implemented")
+	ErrMessageMismatch = errors.New("message type doesn't match the service descriptor")
+)
+
+//nolint:gochecknoglobals
+var (
+	contextType = reflect.TypeFor[context.Context]()
+	errorType   = reflect.TypeFor[error]()
+)
+
+// RegisterService registers every method of impl with the signature
+// func(context.Context, *In) (*Out, error) at "/<service>/<Method>",
+// service should be the full name of the service like "pkg.Service".
+//
+// Other methods get ignored, it returns ErrNoMethods when impl has none.
+// It must be called from a registration function, see NewServiceRegistration.
+func (s *Server) RegisterService(service string, impl any) error {
+	v := reflect.ValueOf(impl)
+	registered := 0
+
+	for i := range v.NumMethod() {
+		m := v.Type().Method(i)
+		if !isHandlerMethod(m.Type) {
+			continue
+		}
+
+		s.registerMethod(service, m.Name, v.Method(i))
+
+		registered++
+	}
+
+	if registered == 0 {
+		return fmt.Errorf("%w: %T", ErrNoMethods, impl)
+	}
+
+	return nil
+}
+
+// RegisterServiceDesc registers the methods of the proto service descriptor at
+// "/<package.Service>/<Method>", impl must implement them with the signature
+// func(context.Context, *In) (*Out, error) and the messages of the descriptor.
+//
+// Methods with a google.api.http option additionally get their RESTful routes.
+//
+// Streaming methods are skipped, use NewStreamHandler for them.
+// It must be called from a registration function, see NewServiceDescRegistration.
+func (s *Server) RegisterServiceDesc(desc protoreflect.ServiceDescriptor, impl any) error {
+	v := reflect.ValueOf(impl)
+	service := string(desc.FullName())
+
+	for i := range desc.Methods().Len() {
+		md := desc.Methods().Get(i)
+		if md.IsStreamingClient() || md.IsStreamingServer() {
+			s.logger.Debug("Skipping streaming method", "service", service, "method", md.Name())
+			continue
+		}
+
+		name := string(md.Name())
+
+		m, ok := v.Type().MethodByName(name)
+		if !ok || !isHandlerMethod(m.Type) {
+			return fmt.Errorf("%w: %s.%s", ErrMissingMethod, service, name)
+		}
+
+		if err := checkMessage(m.Type.In(2), md.Input()); err != nil {
+			return fmt.Errorf("%w: input of %s.%s", err, service, name)
+		}
+
+		if err := checkMessage(m.Type.Out(0), md.Output()); err != nil {
+			return fmt.Errorf("%w: output of %s.%s", err, service, name)
+		}
+
+		s.registerMethod(service, name, v.Method(m.Index))
+
+		if err := s.registerHTTPRules(md, service, v.Method(m.Index)); err != nil {
+			return fmt.Errorf("%w: %s.%s", err, service, name)
+		}
+	}
+
+	return nil
+}
+
+// registerMethod adds the RPC route of a method value.
+func (s *Server) registerMethod(service string, name string, method reflect.Value) {
+	s.addOperation(&operation{
+		service: service,
+		method:  name,
+		in:      method.Type().In(1).Elem(),
+		out:     method.Type().Out(0).Elem(),
+	})
+
+	s.Router().POST("/"+service+"/"+name, s.methodHandler(service, name, method, s.decodeRequest))
+}
+
+// methodHandler returns the Hertz handler for a method value, decode fills the request.
+func (s *Server) methodHandler(
+	service string,
+	name string,
+	method reflect.Value,
+	decode func(apCtx *app.RequestContext, req any) error,
+) func(c context.Context, ctx *app.RequestContext) {
+	in := method.Type().In(1).Elem()
+
+	return s.newHandler(
+		func() any { return reflect.New(in).Interface() },
+		decode,
+		func(ctx context.Context, req any) (any, error) {
+			out := method.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(req)})
+			if err, _ := out[1].Interface().(error); err != nil {
+				return nil, err
+			}
+
+			return out[0].Interface(), nil
+		},
+		service,
+		name,
+		nil,
+	)
+}
+
+// isHandlerMethod returns whether the type of a method, including its receiver,
+// is func(context.Context, *In) (*Out, error).
+func isHandlerMethod(t reflect.Type) bool {
+	return t.NumIn() == 3 && t.NumOut() == 2 &&
+		t.In(1) == contextType &&
+		t.In(2).Kind() == reflect.Pointer &&
+		t.Out(0).Kind() == reflect.Pointer &&
+		t.Out(1) == errorType
+}
+
+// checkMessage checks that t is a pointer to the proto message of the descriptor,
+// types which aren't proto messages get accepted, the codecs decide about them.
+func checkMessage(t reflect.Type, desc protoreflect.MessageDescriptor) error {
+	msg, ok := reflect.Zero(t).Interface().(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	if got := msg.ProtoReflect().Descriptor().FullName(); got != desc.FullName() {
+		return fmt.Errorf("%w: got %s, want %s", ErrMessageMismatch, got, desc.FullName())
+	}
+
+	return nil
+}
+
+// NewServiceRegistration returns a registration function which registers impl
+// with RegisterService, errors get returned by Start.
+func NewServiceRegistration(service string, impl any) orbserver.RegistrationFunc {
+	return func(srv any) {
+		s, ok := srv.(*Server)
+		if !ok {
+			return
+		}
+
+		s.registerErr = errors.Join(s.registerErr, s.RegisterService(service, impl))
+	}
+}
+
+// NewServiceDescRegistration returns a registration function which registers impl
+// with RegisterServiceDesc, errors get returned by Start.
+func NewServiceDescRegistration(desc protoreflect.ServiceDescriptor, impl any) orbserver.RegistrationFunc {
+	return func(srv any) {
+		s, ok := srv.(*Server)
+		if !ok {
+			return
+		}
+
+		s.registerErr = errors.Join(s.registerErr, s.RegisterServiceDesc(desc, impl))
+	}
+}
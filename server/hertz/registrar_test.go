@@ -0,0 +1,117 @@
+package hertz
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// greeter gets registered with reflection.
+type greeter struct{}
+
+func (g *greeter) Hello(_ context.Context, req *codecMsg) (*codecMsg, error) {
+	return &codecMsg{Text: "Hello " + req.Text}, nil
+}
+
+// Ignored doesn't have the signature of a handler.
+func (g *greeter) Ignored(string) {}
+
+// echoService implements the service of echoServiceDesc.
+type echoService struct{}
+
+func (e echoService) Echo(_ context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	return req, nil
+}
+
+// mismatchService implements the service of echoServiceDesc with the wrong input.
+type mismatchService struct{}
+
+func (e mismatchService) Echo(_ context.Context, _ *structpb.Struct) (*wrapperspb.StringValue, error) {
+	return &wrapperspb.StringValue{}, nil
+}
+
+// echoServiceDesc returns the descriptor of the service "test.registrar.EchoService".
+func echoServiceDesc(t *testing.T) protoreflect.ServiceDescriptor {
+	t.Helper()
+
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/registrar/echo.proto"),
+		Package:    proto.String("test.registrar"),
+		Dependency: []string{"google/protobuf/wrappers.proto"},
+		Syntax:     proto.String("proto3"),
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("EchoService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{
+					Name:       proto.String("Echo"),
+					InputType:  proto.String(".google.protobuf.StringValue"),
+					OutputType: proto.String(".google.protobuf.StringValue"),
+				},
+				{
+					Name:            proto.String("EchoStream"),
+					InputType:       proto.String(".google.protobuf.StringValue"),
+					OutputType:      proto.String(".google.protobuf.StringValue"),
+					ClientStreaming: proto.Bool(true),
+					ServerStreaming: proto.Bool(true),
+				},
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+
+	return fd.Services().Get(0)
+}
+
+// postJSON posts the JSON body to the path on the entrypoint.
+func postJSON(t *testing.T, srv *Server, path string, body string) (*http.Response, []byte) {
+	t.Helper()
+
+	return doRequest(t, srv, http.MethodPost, path, []byte(body), map[string]string{"Content-Type": consts.MIMEApplicationJSON})
+}
+
+func TestRegisterService(t *testing.T) {
+	srv := setupServer(t, WithInsecure(), WithHandlers(NewServiceRegistration("test.Greeter", &greeter{})))
+
+	resp, data := postJSON(t, srv, "/test.Greeter/Hello", `{"text":"orb"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.JSONEq(t, `{"text":"Hello orb"}`, string(data))
+
+	resp, _ = postJSON(t, srv, "/test.Greeter/Ignored", `{}`)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestRegisterServiceNoMethods(t *testing.T) {
+	srv := newTestServer(t, WithInsecure(), WithHandlers(NewServiceRegistration("test.Empty", &struct{}{})))
+
+	require.ErrorIs(t, srv.Start(context.Background()), ErrNoMethods)
+}
+
+func TestRegisterServiceDesc(t *testing.T) {
+	srv := setupServer(t, WithInsecure(), WithHandlers(NewServiceDescRegistration(echoServiceDesc(t), echoService{})))
+
+	resp, data := postJSON(t, srv, "/test.registrar.EchoService/Echo", `{"value":"echo"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.JSONEq(t, `{"value":"echo"}`, string(data))
+
+	// Streaming methods get skipped.
+	resp, _ = postJSON(t, srv, "/test.registrar.EchoService/EchoStream", `{}`)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestRegisterServiceDescErrors(t *testing.T) {
+	srv := newTestServer(t, WithInsecure(), WithHandlers(NewServiceDescRegistration(echoServiceDesc(t), &greeter{})))
+	require.ErrorIs(t, srv.Start(context.Background()), ErrMissingMethod)
+
+	srv = newTestServer(t, WithInsecure(), WithHandlers(NewServiceDescRegistration(echoServiceDesc(t), mismatchService{})))
+	require.ErrorIs(t, srv.Start(context.Background()), ErrMessageMismatch)
+}
@@ -0,0 +1,395 @@
+package hertz
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// RESTful route errors.
+var (
+	ErrInvalidHTTPRule      = errors.New("invalid google.api.http rule")
+	ErrUnsupportedTemplate  = errors.New("unsupported path template")
+	ErrUnsupportedFieldPath = errors.New("unsupported field path")
+)
+
+// pathVar binds the hertz route parameter param to the field path of the request.
+type pathVar struct {
+	param string
+	field string
+}
+
+// httpRoute is a RESTful route of a google.api.http rule.
+type httpRoute struct {
+	method string
+	path   string
+	vars   []pathVar
+	body   string
+}
+
+// registerHTTPRules registers the RESTful routes of the google.api.http option of md.
+func (s *Server) registerHTTPRules(md protoreflect.MethodDescriptor, service string, method reflect.Value) error {
+	rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+	if !ok || rule == nil {
+		return nil
+	}
+
+	if !method.Type().In(1).Implements(reflect.TypeFor[proto.Message]()) {
+		return fmt.Errorf("%w: the input must be a proto message", ErrInvalidHTTPRule)
+	}
+
+	routes := []*httpRoute{}
+
+	for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+		route, err := parseHTTPRule(r, md.Input())
+		if err != nil {
+			return err
+		}
+
+		routes = append(routes, route)
+	}
+
+	for _, route := range routes {
+		s.addOperation(&operation{
+			service:    service,
+			method:     string(md.Name()),
+			in:         method.Type().In(1).Elem(),
+			out:        method.Type().Out(0).Elem(),
+			httpMethod: route.method,
+			route:      route.path,
+			vars:       route.vars,
+			body:       route.body,
+		})
+
+		s.Router().Handle(route.method, route.path, s.methodHandler(service, string(md.Name()), method, route.decode(s)))
+	}
+
+	return nil
+}
+
+// parseHTTPRule validates a rule against the input message and translates
+// its path template into a hertz route.
+func parseHTTPRule(rule *annotations.HttpRule, input protoreflect.MessageDescriptor) (*httpRoute, error) {
+	route := &httpRoute{body: rule.GetBody()}
+
+	var template string
+
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		route.method, template = "GET", p.Get
+	case *annotations.HttpRule_Put:
+		route.method, template = "PUT", p.Put
+	case *annotations.HttpRule_Post:
+		route.method, template = "POST", p.Post
+	case *annotations.HttpRule_Delete:
+		route.method, template = "DELETE", p.Delete
+	case *annotations.HttpRule_Patch:
+		route.method, template = "PATCH", p.Patch
+	case *annotations.HttpRule_Custom:
+		route.method, template = p.Custom.GetKind(), p.Custom.GetPath()
+	default:
+		return nil, fmt.Errorf("%w: no pattern", ErrInvalidHTTPRule)
+	}
+
+	if rule.GetResponseBody() != "" {
+		return nil, fmt.Errorf("%w: response_body isn't supported", ErrInvalidHTTPRule)
+	}
+
+	if route.body != "" && route.body != "*" {
+		fd, err := lookupField(input, route.body)
+		if err != nil {
+			return nil, err
+		}
+
+		if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+			return nil, fmt.Errorf("%w: body '%s' must be a message field", ErrInvalidHTTPRule, route.body)
+		}
+	}
+
+	path, vars, err := parseTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range vars {
+		fd, err := lookupField(input, v.field)
+		if err != nil {
+			return nil, err
+		}
+
+		if fd.IsList() || fd.IsMap() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+			return nil, fmt.Errorf("%w: path variable '%s' must be a scalar field", ErrInvalidHTTPRule, v.field)
+		}
+	}
+
+	route.path, route.vars = path, vars
+
+	return route, nil
+}
+
+// parseTemplate translates a path template like "/v1/users/{id}" into the
+// hertz route "/v1/users/:p0".
+//
+// Variables may only match a single segment "{id}" or "{id=*}", or all
+// remaining segments "{path=**}", nested patterns and verbs aren't supported.
+func parseTemplate(template string) (string, []pathVar, error) {
+	if !strings.HasPrefix(template, "/") {
+		return "", nil, fmt.Errorf("%w: '%s' must start with '/'", ErrUnsupportedTemplate, template)
+	}
+
+	segments := strings.Split(template[1:], "/")
+	vars := []pathVar{}
+
+	for i, seg := range segments {
+		last := i == len(segments)-1
+
+		switch {
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			field, pattern, _ := strings.Cut(seg[1:len(seg)-1], "=")
+			param := "p" + strconv.Itoa(len(vars))
+
+			switch pattern {
+			case "", "*":
+				segments[i] = ":" + param
+			case "**":
+				if !last {
+					return "", nil, fmt.Errorf("%w: '%s', '**' must be the last segment", ErrUnsupportedTemplate, template)
+				}
+
+				segments[i] = "*" + param
+			default:
+				return "", nil, fmt.Errorf("%w: '%s', variable pattern '%s'", ErrUnsupportedTemplate, template, pattern)
+			}
+
+			vars = append(vars, pathVar{param: param, field: field})
+		case seg == "*":
+			segments[i] = ":_" + strconv.Itoa(i)
+		case seg == "**" && last:
+			segments[i] = "*_"
+		case strings.ContainsAny(seg, "{}*:"):
+			return "", nil, fmt.Errorf("%w: '%s', segment '%s'", ErrUnsupportedTemplate, template, seg)
+		}
+	}
+
+	return "/" + strings.Join(segments, "/"), vars, nil
+}
+
+// decode returns the request decoder of the route.
+func (r *httpRoute) decode(s *Server) func(apCtx *app.RequestContext, req any) error {
+	return func(apCtx *app.RequestContext, req any) error {
+		pm, ok := req.(proto.Message)
+		if !ok {
+			return orberrors.ErrInternalServerError.Wrap(fmt.Errorf("%w: %T isn't a proto message", ErrInvalidHTTPRule, req))
+		}
+
+		msg := pm.ProtoReflect()
+
+		if err := r.decodeBody(s, apCtx, msg); err != nil {
+			return err
+		}
+
+		bound := map[string]bool{}
+
+		for _, v := range r.vars {
+			value := strings.TrimPrefix(apCtx.Param(v.param), "/")
+			if err := setField(msg, v.field, []string{value}); err != nil {
+				return orberrors.ErrBadRequest.Wrap(err)
+			}
+
+			bound[v.field] = true
+		}
+
+		if r.body == "*" {
+			return nil
+		}
+
+		// All other fields can be set with query parameters.
+		query := map[string][]string{}
+		apCtx.QueryArgs().VisitAll(func(k, v []byte) {
+			query[string(k)] = append(query[string(k)], string(v))
+		})
+
+		for field, values := range query {
+			if bound[field] || (r.body != "" && (field == r.body || strings.HasPrefix(field, r.body+"."))) {
+				continue
+			}
+
+			// Unknown parameters get ignored.
+			if _, err := lookupField(msg.Descriptor(), field); err != nil {
+				continue
+			}
+
+			if err := setField(msg, field, values); err != nil {
+				return orberrors.ErrBadRequest.Wrap(err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// decodeBody decodes the request body into the whole message or the body field.
+func (r *httpRoute) decodeBody(s *Server, apCtx *app.RequestContext, msg protoreflect.Message) error {
+	if r.body == "" || len(apCtx.Request.Body()) == 0 {
+		return nil
+	}
+
+	if r.body == "*" {
+		_, err := s.unmarshalBody(apCtx, msg.Interface())
+		return err
+	}
+
+	fd, err := lookupField(msg.Descriptor(), r.body)
+	if err != nil {
+		return orberrors.ErrInternalServerError.Wrap(err)
+	}
+
+	parent, err := fieldParent(msg, r.body)
+	if err != nil {
+		return orberrors.ErrInternalServerError.Wrap(err)
+	}
+
+	_, err = s.unmarshalBody(apCtx, parent.Mutable(fd).Message().Interface())
+
+	return err
+}
+
+// lookupField returns the descriptor of the field at the dot separated path,
+// the path may use the proto or the JSON names of the fields.
+func lookupField(md protoreflect.MessageDescriptor, path string) (protoreflect.FieldDescriptor, error) {
+	names := strings.Split(path, ".")
+
+	for i, name := range names {
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(name)
+		}
+
+		if fd == nil {
+			return nil, fmt.Errorf("%w: '%s' not found in %s", ErrUnsupportedFieldPath, path, md.FullName())
+		}
+
+		if i == len(names)-1 {
+			return fd, nil
+		}
+
+		if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+			return nil, fmt.Errorf("%w: '%s', '%s' isn't a message", ErrUnsupportedFieldPath, path, name)
+		}
+
+		md = fd.Message()
+	}
+
+	return nil, fmt.Errorf("%w: empty path", ErrUnsupportedFieldPath)
+}
+
+// fieldParent returns the message which contains the last field of the path,
+// messages on the way get created.
+func fieldParent(msg protoreflect.Message, path string) (protoreflect.Message, error) {
+	names := strings.Split(path, ".")
+
+	for _, name := range names[:len(names)-1] {
+		fd, err := lookupField(msg.Descriptor(), name)
+		if err != nil {
+			return nil, err
+		}
+
+		msg = msg.Mutable(fd).Message()
+	}
+
+	return msg, nil
+}
+
+// setField parses the values into the field at path, only repeated fields take more than one value.
+func setField(msg protoreflect.Message, path string, values []string) error {
+	fd, err := lookupField(msg.Descriptor(), path)
+	if err != nil {
+		return err
+	}
+
+	if fd.IsMap() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+		return fmt.Errorf("%w: '%s' isn't a scalar field", ErrUnsupportedFieldPath, path)
+	}
+
+	parent, err := fieldParent(msg, path)
+	if err != nil {
+		return err
+	}
+
+	if !fd.IsList() && len(values) > 1 {
+		return fmt.Errorf("%w: '%s' got multiple values", ErrUnsupportedFieldPath, path)
+	}
+
+	for _, s := range values {
+		v, err := parseScalar(fd, s)
+		if err != nil {
+			return fmt.Errorf("field '%s': %w", path, err)
+		}
+
+		if fd.IsList() {
+			parent.Mutable(fd).List().Append(v)
+		} else {
+			parent.Set(fd, v)
+		}
+	}
+
+	return nil
+}
+
+// parseScalar parses a path or query parameter into a value of the fields kind.
+//
+//nolint:cyclop,gocyclo
+func parseScalar(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(v)), err
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(v), err
+	case protoreflect.BytesKind:
+		v, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			v, err = base64.URLEncoding.DecodeString(s)
+		}
+
+		return protoreflect.ValueOfBytes(v), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+
+		v, err := strconv.ParseInt(s, 10, 32)
+
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), err
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+	}
+
+	return protoreflect.Value{}, fmt.Errorf("%w: kind %s", ErrUnsupportedFieldPath, fd.Kind())
+}
@@ -0,0 +1,194 @@
+package hertz
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/sourcecontextpb"
+	"google.golang.org/protobuf/types/known/typepb"
+)
+
+// fieldService echos the requests of fieldServiceDesc.
+type fieldService struct{}
+
+func (f fieldService) GetField(_ context.Context, req *typepb.Field) (*typepb.Field, error) {
+	return req, nil
+}
+
+func (f fieldService) PutField(_ context.Context, req *typepb.Field) (*typepb.Field, error) {
+	return req, nil
+}
+
+func (f fieldService) UpdateType(_ context.Context, req *typepb.Type) (*typepb.Type, error) {
+	return req, nil
+}
+
+// restMethod returns a method descriptor with a google.api.http rule.
+func restMethod(name string, msg string, rule *annotations.HttpRule) *descriptorpb.MethodDescriptorProto {
+	opts := &descriptorpb.MethodOptions{}
+	proto.SetExtension(opts, annotations.E_Http, rule)
+
+	return &descriptorpb.MethodDescriptorProto{
+		Name:       proto.String(name),
+		InputType:  proto.String(msg),
+		OutputType: proto.String(msg),
+		Options:    opts,
+	}
+}
+
+// fieldServiceDesc returns the descriptor of the service "test.rest.FieldService".
+func fieldServiceDesc(t *testing.T, rules map[string]*annotations.HttpRule) protoreflect.ServiceDescriptor {
+	t.Helper()
+
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/rest/field.proto"),
+		Package:    proto.String("test.rest"),
+		Dependency: []string{"google/protobuf/type.proto"},
+		Syntax:     proto.String("proto3"),
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("FieldService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				restMethod("GetField", ".google.protobuf.Field", rules["GetField"]),
+				restMethod("PutField", ".google.protobuf.Field", rules["PutField"]),
+				restMethod("UpdateType", ".google.protobuf.Type", rules["UpdateType"]),
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+
+	return fd.Services().Get(0)
+}
+
+func restRules() map[string]*annotations.HttpRule {
+	return map[string]*annotations.HttpRule{
+		"GetField": {
+			Pattern: &annotations.HttpRule_Get{Get: "/v1/fields/{name}"},
+			AdditionalBindings: []*annotations.HttpRule{
+				{Pattern: &annotations.HttpRule_Get{Get: "/v1/files/{type_url=**}"}},
+				{Pattern: &annotations.HttpRule_Delete{Delete: "/v1/fields/{name}"}},
+			},
+		},
+		"PutField": {
+			Pattern: &annotations.HttpRule_Put{Put: "/v1/fields/{name}"},
+			Body:    "*",
+		},
+		"UpdateType": {
+			Pattern: &annotations.HttpRule_Patch{Patch: "/v1/types/{name}"},
+			Body:    "source_context",
+		},
+	}
+}
+
+// doREST sends a request with a protobuf body and decodes the protobuf response into out.
+func doREST(t *testing.T, srv *Server, method string, uri string, in proto.Message, out proto.Message) int {
+	t.Helper()
+
+	header := map[string]string{"Accept": codecs.MimeProto}
+
+	var body []byte
+
+	if in != nil {
+		var err error
+
+		body, err = proto.Marshal(in)
+		require.NoError(t, err)
+
+		header["Content-Type"] = codecs.MimeProto
+	}
+
+	resp, data := doRequest(t, srv, method, uri, body, header)
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, proto.Unmarshal(data, out))
+	}
+
+	return resp.StatusCode
+}
+
+func TestRESTRoutes(t *testing.T) {
+	srv := setupServer(t, WithInsecure(), WithHandlers(NewServiceDescRegistration(fieldServiceDesc(t, restRules()), fieldService{})))
+
+	t.Run("GET with query", func(t *testing.T) {
+		out := &typepb.Field{}
+		code := doREST(t, srv, http.MethodGet,
+			"/v1/fields/user?number=7&kind=TYPE_STRING&packed=true&jsonName=userName&unknown=1", nil, out)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "user", out.GetName())
+		require.Equal(t, int32(7), out.GetNumber())
+		require.Equal(t, typepb.Field_TYPE_STRING, out.GetKind())
+		require.True(t, out.GetPacked())
+		require.Equal(t, "userName", out.GetJsonName())
+	})
+
+	t.Run("GET catch-all", func(t *testing.T) {
+		out := &typepb.Field{}
+		require.Equal(t, http.StatusOK, doREST(t, srv, http.MethodGet, "/v1/files/a/b/c.proto", nil, out))
+		require.Equal(t, "a/b/c.proto", out.GetTypeUrl())
+	})
+
+	t.Run("DELETE", func(t *testing.T) {
+		out := &typepb.Field{}
+		require.Equal(t, http.StatusOK, doREST(t, srv, http.MethodDelete, "/v1/fields/gone", nil, out))
+		require.Equal(t, "gone", out.GetName())
+	})
+
+	t.Run("PUT with body", func(t *testing.T) {
+		out := &typepb.Field{}
+		code := doREST(t, srv, http.MethodPut, "/v1/fields/user",
+			&typepb.Field{Name: "ignored", Number: 3}, out)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "user", out.GetName())
+		require.Equal(t, int32(3), out.GetNumber())
+	})
+
+	t.Run("PATCH with body field", func(t *testing.T) {
+		out := &typepb.Type{}
+		code := doREST(t, srv, http.MethodPatch, "/v1/types/User?syntax=SYNTAX_PROTO3",
+			&sourcecontextpb.SourceContext{FileName: "user.proto"}, out)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "User", out.GetName())
+		require.Equal(t, typepb.Syntax_SYNTAX_PROTO3, out.GetSyntax())
+		require.Equal(t, "user.proto", out.GetSourceContext().GetFileName())
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest,
+			doREST(t, srv, http.MethodGet, "/v1/fields/user?number=seven", nil, &typepb.Field{}))
+	})
+
+	t.Run("RPC route", func(t *testing.T) {
+		out := &typepb.Field{}
+		code := doREST(t, srv, http.MethodPost, "/test.rest.FieldService/PutField",
+			&typepb.Field{Name: "rpc"}, out)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "rpc", out.GetName())
+	})
+}
+
+func TestRESTInvalidRules(t *testing.T) {
+	tests := map[string]*annotations.HttpRule{
+		"nested pattern": {Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=fields/*}"}},
+		"verb":           {Pattern: &annotations.HttpRule_Get{Get: "/v1/fields/{name}:get"}},
+		"unknown field":  {Pattern: &annotations.HttpRule_Get{Get: "/v1/fields/{id}"}},
+		"scalar body":    {Pattern: &annotations.HttpRule_Post{Post: "/v1/fields"}, Body: "name"},
+		"response body":  {Pattern: &annotations.HttpRule_Get{Get: "/v1/fields"}, ResponseBody: "name"},
+	}
+
+	for name, rule := range tests {
+		t.Run(name, func(t *testing.T) {
+			rules := restRules()
+			rules["GetField"] = rule
+
+			srv := newTestServer(t, WithInsecure(), WithHandlers(NewServiceDescRegistration(fieldServiceDesc(t, rules), fieldService{})))
+			require.Error(t, srv.Start(context.Background()))
+		})
+	}
+}
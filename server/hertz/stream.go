@@ -0,0 +1,302 @@
+package hertz
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/network"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/util/orberrors"
+	"github.com/hertz-contrib/http2"
+)
+
+// Streams are sent over HTTP/2 as a sequence of frames in both directions,
+// each frame is a 1 byte flag, followed by the 4 byte big endian length of the
+// payload and the payload itself.
+//
+// The client half-closes the stream by closing the request body, the server
+// ends the response with a frame flagged frameFlagEnd which contains the
+// status of the stream as JSON errorBody, code 200 means success.
+const (
+	frameHeaderLen = 5
+
+	frameFlagEnd byte = 0x80
+)
+
+// Stream errors.
+var (
+	ErrStreamRequiresHTTP2 = errors.New("streaming requires a HTTP/2 connection")
+	ErrMessageTooLarge     = errors.New("stream message too large")
+)
+
+// ServerStream is the server side of a bidirectional stream, see NewStreamHandler.
+//
+// It's safe to have one goroutine calling Send and another one calling Recv
+// at the same time, but not to call Send or Recv from multiple goroutines.
+type ServerStream[TReq any, TResp any] interface {
+	// Context returns the context of the stream, it contains the incoming metadata
+	// and gets canceled once the stream ends.
+	Context() context.Context
+
+	// Recv receives the next message from the client, it returns io.EOF
+	// once the client has closed its send direction.
+	Recv() (*TReq, error)
+
+	// Send sends a message to the client.
+	Send(msg *TResp) error
+}
+
+type serverStream[TReq any, TResp any] struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	apCtx   *app.RequestContext
+	decoder codecs.Marshaler
+	encoder codecs.Marshaler
+	body    io.Reader
+	writer  network.ExtWriter
+
+	// maxMsgSize is the maximum size of a received message.
+	maxMsgSize int
+
+	outMd       map[string]string
+	wroteHeader bool
+}
+
+func (s *serverStream[TReq, TResp]) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream[TReq, TResp]) Recv() (*TReq, error) {
+	_, payload, err := readFrame(s.body, s.maxMsgSize)
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			s.cancel()
+		}
+
+		return nil, err
+	}
+
+	msg := new(TReq)
+	if err := s.decoder.Unmarshal(payload, msg); err != nil {
+		return nil, orberrors.ErrBadRequest.Wrap(err)
+	}
+
+	return msg, nil
+}
+
+func (s *serverStream[TReq, TResp]) Send(msg *TResp) error {
+	payload, err := s.encoder.Marshal(msg)
+	if err != nil {
+		return orberrors.ErrInternalServerError.Wrap(err)
+	}
+
+	if err := s.writeFrame(0, payload); err != nil {
+		s.cancel()
+		return err
+	}
+
+	return nil
+}
+
+// writeHeader writes the outgoing metadata before the first frame.
+func (s *serverStream[TReq, TResp]) writeHeader() {
+	if s.wroteHeader {
+		return
+	}
+
+	for k, v := range s.outMd {
+		s.apCtx.Header(k, v)
+	}
+
+	s.wroteHeader = true
+}
+
+func (s *serverStream[TReq, TResp]) writeFrame(flags byte, payload []byte) error {
+	s.writeHeader()
+
+	if err := writeFrame(s.writer, flags, payload); err != nil {
+		return err
+	}
+
+	return s.writer.Flush()
+}
+
+// finish writes the end frame with the status of the handler.
+func (s *serverStream[TReq, TResp]) finish(err error) error {
+	defer s.cancel()
+
+	status := &errorBody{Code: consts.StatusOK}
+
+	if err != nil {
+		code := orberrors.From(err).Code
+
+		status = newResponseErrorBody(code, err)
+		if status.Code == 0 {
+			status.Code = code
+		}
+	}
+
+	payload, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	return s.writeFrame(frameFlagEnd, payload)
+}
+
+// NewStreamHandler wraps a bidirectional stream handler with a Hertz handler.
+//
+// Streaming requires HTTP/2, either h2c or HTTP/2 over TLS, HTTP/1 requests
+// get a "505 HTTP Version Not Supported" error.
+//
+// Each received message may be up to Config.MaxRequestBodyBytes, see WithRouteMaxRequestBodyBytes.
+//
+// The entrypoint middlewares and those of WithRouteMiddlewares run once per
+// stream, their request is the ServerStream and their response is nil.
+// An error of a middleware ends the stream with that error.
+func NewStreamHandler[Tin any, Tout any](
+	srv *Server,
+	fHandler func(stream ServerStream[Tin, Tout]) error,
+	service string,
+	method string,
+	opts ...HandlerOption,
+) func(c context.Context, ctx *app.RequestContext) {
+	hOpts := newHandlerOptions(opts)
+	maxMsgSize := srv.bodyLimit(hOpts)
+
+	// The middlewares get the stream as request, the context they pass on becomes its context.
+	h := chainMiddlewares(func(ctx context.Context, req any) (any, error) {
+		stream := req.(*serverStream[Tin, Tout]) //nolint:errcheck,forcetypeassert
+		stream.ctx = ctx
+
+		return nil, fHandler(stream)
+	}, srv.routeMiddlewares(hOpts))
+
+	srv.addOperation(&operation{
+		service: service,
+		method:  method,
+		in:      reflect.TypeFor[Tin](),
+		out:     reflect.TypeFor[Tout](),
+		stream:  true,
+	})
+
+	return func(ctx context.Context, apCtx *app.RequestContext) {
+		srv.setRequestLabels(apCtx, service, method)
+
+		writer, err := http2.NewResponseWriter(apCtx.GetConn())
+		if err != nil {
+			WriteError(apCtx, orberrors.HTTP(consts.StatusHTTPVersionNotSupported).Wrap(ErrStreamRequiresHTTP2))
+
+			return
+		}
+
+		ct, err := GetContentType(string(apCtx.ContentType()))
+		if err != nil {
+			WriteError(apCtx, orberrors.ErrBadRequest.Wrap(err))
+
+			return
+		}
+
+		decoder, err := codecs.GetDecoder(ct, new(Tin))
+		if err != nil {
+			WriteError(apCtx, codecError(consts.StatusUnsupportedMediaType, ct))
+
+			return
+		}
+
+		encoder, err := codecs.GetEncoder(ct, new(Tout))
+		if err != nil {
+			WriteError(apCtx, codecError(consts.StatusUnsupportedMediaType, ct))
+
+			return
+		}
+
+		ctx, cancel, err := requestContext(ctx, apCtx)
+		if err != nil {
+			WriteError(apCtx, err)
+
+			return
+		}
+
+		ctx, outMd := incomingMetadata(ctx, apCtx, service, method)
+
+		apCtx.Response.HijackWriter(writer)
+		apCtx.SetContentType(ct)
+
+		stream := &serverStream[Tin, Tout]{
+			ctx:     ctx,
+			cancel:  cancel,
+			apCtx:   apCtx,
+			decoder: decoder,
+			encoder: encoder,
+			body:    rawBodyStream(apCtx),
+			writer:  writer,
+			outMd:   outMd,
+
+			maxMsgSize: maxMsgSize,
+		}
+
+		_, herr := h(ctx, stream)
+		if herr != nil {
+			srv.logError(ctx, "stream request failed", herr)
+		}
+
+		if err := stream.finish(herr); err != nil {
+			srv.logError(ctx, "failed to finish the stream", err)
+		}
+	}
+}
+
+// readFrame reads a single frame of up to maxSize bytes, DefaultMaxRequestBodyBytes
+// if it's 0. It returns io.EOF if the stream ended before a frame.
+func readFrame(r io.Reader, maxSize int) (byte, []byte, error) {
+	var header [frameHeaderLen]byte
+
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	if maxSize <= 0 {
+		maxSize = DefaultMaxRequestBodyBytes
+	}
+
+	// Check the length before allocating, it comes from the wire.
+	length := binary.BigEndian.Uint32(header[1:])
+	if int64(length) > int64(maxSize) {
+		return 0, nil, orberrors.HTTP(consts.StatusRequestEntityTooLarge).Wrap(ErrMessageTooLarge)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+
+		return 0, nil, err
+	}
+
+	return header[0], payload, nil
+}
+
+// writeFrame writes a single frame.
+func writeFrame(w io.Writer, flags byte, payload []byte) error {
+	var header [frameHeaderLen]byte
+
+	header[0] = flags
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload))) //nolint:gosec
+
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+
+	_, err := w.Write(payload)
+
+	return err
+}
@@ -0,0 +1,38 @@
+package hertz
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFrameLimit(t *testing.T) {
+	frame := func(length uint32) *bytes.Reader {
+		header := make([]byte, frameHeaderLen)
+		binary.BigEndian.PutUint32(header[1:], length)
+
+		return bytes.NewReader(header)
+	}
+
+	// Without a max size the default applies before anything gets allocated.
+	_, _, err := readFrame(frame(math.MaxUint32), 0)
+	require.ErrorIs(t, err, ErrMessageTooLarge)
+
+	_, _, err = readFrame(frame(DefaultMaxRequestBodyBytes+1), 0)
+	require.ErrorIs(t, err, ErrMessageTooLarge)
+
+	_, _, err = readFrame(frame(11), 10)
+	require.ErrorIs(t, err, ErrMessageTooLarge)
+
+	// The length is fine, but the payload is missing.
+	_, _, err = readFrame(frame(10), 10)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// An empty stream ends cleanly.
+	_, _, err = readFrame(bytes.NewReader(nil), 10)
+	require.ErrorIs(t, err, io.EOF)
+}
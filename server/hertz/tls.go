@@ -0,0 +1,144 @@
+package hertz
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+
+	mtls "github.com/go-orb/go-orb/util/tls"
+)
+
+// ALPN protocol IDs.
+const (
+	alpnH2    = "h2"
+	alpnHTTP1 = "http/1.1"
+)
+
+// secure returns whether the entrypoint serves HTTPS.
+func (s *Server) secure() bool {
+	return !s.config.Insecure && !s.config.H2C
+}
+
+// tlsConfig creates the servers TLS config out of Config.TLS, it generates
+// an ephemeral self-signed certificate when no certificate has been configured.
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	var tlsConfig *tls.Config
+
+	switch {
+	case s.config.TLS == nil:
+		tlsConfig = &tls.Config{} //nolint:gosec
+	case s.config.TLS.Config != nil:
+		tlsConfig = s.config.TLS.Config.Clone()
+	default:
+		var err error
+
+		tlsConfig, err = loadTLSConfigFiles(s.config.TLS.ConfigFiles)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tlsConfig.Certificates) == 0 && tlsConfig.GetCertificate == nil {
+		s.logger.Warn("No TLS certificate configured, generating a self-signed one")
+
+		hosts := []string{"localhost"}
+		if !s.isUnix() {
+			hosts = certificateHosts(s.address)
+		}
+
+		cert, _, err := mtls.Certificate(hosts...)
+		if err != nil {
+			return nil, fmt.Errorf("while generating a self-signed certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if tlsConfig.MinVersion == 0 {
+		tlsConfig.MinVersion = tls.VersionTLS12
+	}
+
+	// Keep the configured protocols and only add the missing ones. Hertz appends
+	// "http/1.1" once more when ALPN is enabled, the duplicate doesn't matter.
+	if s.config.HTTP2 && !slices.Contains(tlsConfig.NextProtos, alpnH2) {
+		tlsConfig.NextProtos = append(tlsConfig.NextProtos, alpnH2)
+	}
+
+	if !slices.Contains(tlsConfig.NextProtos, alpnHTTP1) {
+		tlsConfig.NextProtos = append(tlsConfig.NextProtos, alpnHTTP1)
+	}
+
+	return tlsConfig, nil
+}
+
+// loadTLSConfigFiles loads a tls.Config from the files given in a mtls.ConfigFiles.
+func loadTLSConfigFiles(files mtls.ConfigFiles) (*tls.Config, error) {
+	tlsConfig := &tls.Config{ //nolint:gosec
+		ClientAuth: files.ClientAuth.ClientAuthType,
+	}
+
+	if len(files.ClientCAFiles) > 0 {
+		pool, err := loadCertPool(files.ClientCAFiles)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.ClientCAs = pool
+	}
+
+	if len(files.RootCAFiles) > 0 {
+		pool, err := loadCertPool(files.RootCAFiles)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	for _, kp := range files.Certificates {
+		cert, err := tls.LoadX509KeyPair(kp.CertFile, kp.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("while loading the certificate '%s': %w", kp.CertFile, err)
+		}
+
+		tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+	}
+
+	return tlsConfig, nil
+}
+
+func loadCertPool(files []string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+
+	for _, f := range files {
+		pem, err := os.ReadFile(filepath.Clean(f))
+		if err != nil {
+			return nil, err
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in '%s'", f)
+		}
+	}
+
+	return pool, nil
+}
+
+// certificateHosts returns the hosts a self-signed certificate should be valid for.
+func certificateHosts(address string) []string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+
+	ip := net.ParseIP(host)
+	if host == "" || (ip != nil && ip.IsUnspecified()) {
+		return []string{"localhost", "127.0.0.1", "::1"}
+	}
+
+	return []string{host}
+}
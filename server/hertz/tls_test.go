@@ -0,0 +1,117 @@
+package hertz
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	mtls "github.com/go-orb/go-orb/util/tls"
+	"github.com/stretchr/testify/require"
+)
+
+// negotiate runs a TLS handshake with the entrypoint offering the protocols,
+// it returns the connection state.
+func negotiate(t *testing.T, srv *Server, protos ...string) tls.ConnectionState {
+	t.Helper()
+
+	conn, err := tls.Dial("tcp", srv.Address(), &tls.Config{
+		InsecureSkipVerify: true, //nolint:gosec
+		NextProtos:         protos,
+	})
+	require.NoError(t, err)
+
+	defer conn.Close() //nolint:errcheck
+
+	return conn.ConnectionState()
+}
+
+func TestTLSSelfSigned(t *testing.T) {
+	srv := setupServer(t, WithAddress("127.0.0.1:0"), withPing())
+
+	require.Equal(t, "hertzhttps", srv.Transport())
+
+	state := negotiate(t, srv, alpnH2, alpnHTTP1)
+	require.Equal(t, alpnH2, state.NegotiatedProtocol)
+	require.NotEmpty(t, state.PeerCertificates)
+
+	host, _, err := net.SplitHostPort(srv.Address())
+	require.NoError(t, err)
+	require.NoError(t, state.PeerCertificates[0].VerifyHostname(host))
+
+	require.Equal(t, alpnHTTP1, negotiate(t, srv, alpnHTTP1).NegotiatedProtocol)
+}
+
+func TestTLSProtocols(t *testing.T) {
+	srv := setupServer(t, WithAddress("127.0.0.1:0"), withPing())
+
+	tests := []struct {
+		name      string
+		transport http.RoundTripper
+		protocol  string
+		major     int
+	}{
+		{
+			name:      "http/1.1",
+			transport: httpClient(srv).Transport,
+			protocol:  alpnHTTP1,
+			major:     1,
+		},
+		{
+			name: "h2",
+			transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+				ForceAttemptHTTP2: true,
+			},
+			protocol: alpnH2,
+			major:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: tt.transport}
+			defer client.CloseIdleConnections()
+
+			resp, err := client.Get(serverURL(srv, pingPath))
+			require.NoError(t, err)
+
+			defer resp.Body.Close() //nolint:errcheck
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, "pong", string(body))
+			require.Equal(t, tt.major, resp.ProtoMajor)
+			require.NotNil(t, resp.TLS)
+			require.Equal(t, tt.protocol, resp.TLS.NegotiatedProtocol)
+		})
+	}
+}
+
+func TestTLSNextProtos(t *testing.T) {
+	cert, _, err := mtls.Certificate("127.0.0.1")
+	require.NoError(t, err)
+
+	// The configured order wins, the missing "h2" gets added after it.
+	tlsConfig := &tls.Config{ //nolint:gosec
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{alpnHTTP1},
+	}
+
+	srv := setupServer(t, WithAddress("127.0.0.1:0"), WithTLS(tlsConfig))
+
+	require.Equal(t, alpnHTTP1, negotiate(t, srv, alpnH2, alpnHTTP1).NegotiatedProtocol)
+	require.Equal(t, alpnH2, negotiate(t, srv, alpnH2).NegotiatedProtocol)
+
+	// The config of the user stays untouched.
+	require.Equal(t, []string{alpnHTTP1}, tlsConfig.NextProtos)
+}
+
+func TestTLSDisableHTTP2(t *testing.T) {
+	srv := setupServer(t, WithAddress("127.0.0.1:0"), WithDisableHTTP2())
+
+	require.Equal(t, alpnHTTP1, negotiate(t, srv, alpnH2, alpnHTTP1).NegotiatedProtocol)
+}
@@ -0,0 +1,108 @@
+package hertz
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the spans.
+const tracerName = "github.com/go-orb/plugins-experimental/server/hertz"
+
+// Span attributes, the names follow the OpenTelemetry semantic conventions.
+const (
+	attrRPCSystem        = attribute.Key("rpc.system")
+	attrRPCService       = attribute.Key("rpc.service")
+	attrRPCMethod        = attribute.Key("rpc.method")
+	attrStatusCode       = attribute.Key("http.response.status_code")
+	attrRequestBodySize  = attribute.Key("http.request.body.size")
+	attrResponseBodySize = attribute.Key("http.response.body.size")
+)
+
+var _ propagation.TextMapCarrier = (*headerCarrier)(nil)
+
+// headerCarrier adapts the request headers for propagators.
+type headerCarrier struct {
+	h *protocol.RequestHeader
+}
+
+func (c headerCarrier) Get(key string) string {
+	return c.h.Get(key)
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c.h.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := []string{}
+
+	c.h.VisitAll(func(k, _ []byte) {
+		keys = append(keys, string(k))
+	})
+
+	return keys
+}
+
+// tracer returns the tracer of Config.TracerProvider.
+func (s *Server) tracer() trace.Tracer {
+	tp := s.config.TracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	return tp.Tracer(tracerName)
+}
+
+// propagator returns Config.Propagator.
+func (s *Server) propagator() propagation.TextMapPropagator {
+	if s.config.Propagator == nil {
+		return propagation.TraceContext{}
+	}
+
+	return s.config.Propagator
+}
+
+// startSpan extracts the trace context of the client and starts the server span of a RPC.
+func (s *Server) startSpan(
+	ctx context.Context,
+	apCtx *app.RequestContext,
+	service string,
+	method string,
+) (context.Context, trace.Span) {
+	ctx = s.propagator().Extract(ctx, headerCarrier{&apCtx.Request.Header})
+
+	return s.tracer().Start(ctx, service+"/"+method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attrRPCSystem.String("orb"),
+			attrRPCService.String(service),
+			attrRPCMethod.String(method),
+		),
+	)
+}
+
+// endSpan adds the status and sizes of the response and ends the span,
+// server errors mark the span as failed.
+func endSpan(span trace.Span, apCtx *app.RequestContext) {
+	code := apCtx.Response.StatusCode()
+
+	span.SetAttributes(
+		attrStatusCode.Int(code),
+		attrRequestBodySize.Int(requestSize(apCtx)),
+		attrResponseBodySize.Int(len(apCtx.Response.Body())),
+	)
+
+	if code >= consts.StatusInternalServerError {
+		span.SetStatus(codes.Error, consts.StatusMessage(code))
+	}
+
+	span.End()
+}
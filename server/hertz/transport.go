@@ -0,0 +1,310 @@
+package hertz
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	hconfig "github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/network"
+
+	"github.com/go-orb/plugins-experimental/server/hertz/internal/bufconn"
+)
+
+var (
+	_ network.Transporter = (*listenerTransport)(nil)
+	_ network.Conn        = (*conn)(nil)
+	_ network.ConnTLSer   = (*tlsConn)(nil)
+)
+
+// listenerTransport is a hertz transporter which serves on a listener created by the entrypoint.
+//
+// Hertz' own transporters always create their listener, this one allows the
+// entrypoint to bind the address before hertz starts, so there's no window
+// in which another process can grab the port.
+type listenerTransport struct {
+	ln net.Listener
+
+	tls            *tls.Config
+	readBufferSize int
+	onAccept       func(conn net.Conn) context.Context
+	onConnect      func(ctx context.Context, conn network.Conn) context.Context
+
+	// serving gets closed once hertz has started to accept connections.
+	serving     chan struct{}
+	servingOnce sync.Once
+
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+	shutdown bool
+	draining bool
+
+	// idleClosed makes the connections fail their next read, see CloseIdle.
+	idleClosed atomic.Bool
+
+	// closed gets closed by Shutdown, hertz expects ListenAndServe to run until then.
+	closed     chan struct{}
+	closedOnce sync.Once
+}
+
+func newListenerTransport(ln net.Listener) *listenerTransport {
+	return &listenerTransport{
+		ln:      ln,
+		serving: make(chan struct{}),
+		conns:   make(map[net.Conn]struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+// newer is given to server.WithTransport, it takes the remaining settings from the hertz options.
+func (t *listenerTransport) newer(opts *hconfig.Options) network.Transporter {
+	t.tls = opts.TLS
+	t.readBufferSize = opts.ReadBufferSize
+	t.onAccept = opts.OnAccept
+	t.onConnect = opts.OnConnect
+
+	return t
+}
+
+// Serving returns a channel which gets closed once the transport accepts connections.
+func (t *listenerTransport) Serving() <-chan struct{} {
+	return t.serving
+}
+
+// ListenAndServe accepts connections until the transport shuts down.
+func (t *listenerTransport) ListenAndServe(onData network.OnData) error {
+	t.servingOnce.Do(func() { close(t.serving) })
+
+	for {
+		c, err := t.ln.Accept()
+		if err != nil {
+			if t.isClosing() {
+				<-t.closed
+				return nil
+			}
+
+			return err
+		}
+
+		ctx := context.Background()
+		if t.onAccept != nil {
+			ctx = t.onAccept(c)
+		}
+
+		var hc network.Conn
+		if t.tls != nil {
+			hc = newTLSConn(tls.Server(c, t.tls), t.readBufferSize, &t.idleClosed)
+		} else {
+			hc = newConn(c, t.readBufferSize, &t.idleClosed)
+		}
+
+		if t.onConnect != nil {
+			ctx = t.onConnect(ctx, hc)
+		}
+
+		if !t.track(c) {
+			_ = c.Close() //nolint:errcheck
+
+			continue
+		}
+
+		// Handlers of HTTP/2 streams get this context, cancel it with the connection.
+		ctx, cancel := context.WithCancel(ctx)
+
+		go func() {
+			defer t.untrack(c)
+			defer cancel()
+
+			_ = onData(ctx, hc) //nolint:errcheck
+		}()
+	}
+}
+
+// Close closes the listener and all connections immediately.
+func (t *listenerTransport) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	return t.Shutdown(ctx)
+}
+
+// Shutdown closes the listener and waits for the connections to finish,
+// the remaining connections get closed when the context is done.
+func (t *listenerTransport) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	t.shutdown = true
+	err := t.ln.Close()
+	t.mu.Unlock()
+
+	t.closedOnce.Do(func() { close(t.closed) })
+
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		t.mu.Lock()
+		for c := range t.conns {
+			_ = c.Close() //nolint:errcheck
+		}
+		t.mu.Unlock()
+
+		return ctx.Err()
+	}
+}
+
+// StopAccepting closes the listener while the open connections keep being served.
+func (t *listenerTransport) StopAccepting() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.draining = true
+
+	if err := t.ln.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// CloseIdle makes the connections which wait for the next request stop waiting,
+// the pending reads fail as if they had timed out and hertz closes them.
+// Connections which write a response aren't affected by that.
+func (t *listenerTransport) CloseIdle() {
+	t.idleClosed.Store(true)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for c := range t.conns {
+		_ = c.SetReadDeadline(time.Now()) //nolint:errcheck
+	}
+}
+
+// isClosing returns whether the listener has been closed by StopAccepting or Shutdown.
+func (t *listenerTransport) isClosing() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.shutdown || t.draining
+}
+
+// track adds a connection, it returns false when the transport is shutting down.
+func (t *listenerTransport) track(c net.Conn) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.shutdown || t.draining {
+		return false
+	}
+
+	t.conns[c] = struct{}{}
+	t.wg.Add(1)
+
+	return true
+}
+
+func (t *listenerTransport) untrack(c net.Conn) {
+	t.mu.Lock()
+	delete(t.conns, c)
+	t.mu.Unlock()
+
+	t.wg.Done()
+}
+
+// conn is the buffered connection of the server, it closes idle connections
+// and senses disconnects.
+type conn struct {
+	*bufconn.Conn
+
+	// idleClosed is the flag of the transport, see listenerTransport.CloseIdle.
+	idleClosed *atomic.Bool
+}
+
+func newConn(c net.Conn, readBufferSize int, idleClosed *atomic.Bool) *conn {
+	return &conn{Conn: bufconn.New(c, readBufferSize, 0), idleClosed: idleClosed}
+}
+
+func (c *conn) SetReadTimeout(t time.Duration) error {
+	if c.idleClosed != nil && c.idleClosed.Load() {
+		// Hertz sets the idle timeout before it waits for the next request.
+		return c.SetReadDeadline(time.Now())
+	}
+
+	return c.Conn.SetReadTimeout(t)
+}
+
+// senseDisconnect reads from the connection in the background while a handler
+// runs and calls cancel when the client closes the connection. The returned
+// stop function must be called before hertz reads from the connection again.
+//
+// The read timeout of the request doesn't apply to the handler, its deadline
+// comes from the TimeoutHeader.
+func (c *conn) senseDisconnect(cancel context.CancelFunc) func() {
+	done := make(chan struct{})
+	stopped := atomic.Bool{}
+
+	_ = c.SetReadDeadline(time.Time{}) //nolint:errcheck
+
+	go func() {
+		defer close(done)
+
+		// Data gets buffered for the next request, EOF and resets are the clients close.
+		if _, err := c.Peek(c.Len() + 1); err != nil && !stopped.Load() && !isTimeout(err) {
+			cancel()
+		}
+	}()
+
+	return func() {
+		stopped.Store(true)
+
+		// Abort the pending read.
+		_ = c.SetReadDeadline(time.Unix(1, 0)) //nolint:errcheck
+
+		<-done
+
+		_ = c.SetReadDeadline(time.Time{}) //nolint:errcheck
+	}
+}
+
+// isTimeout returns whether err is a timeout of a read deadline, CloseIdle sets them.
+func isTimeout(err error) bool {
+	var netErr net.Error
+
+	return errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// tlsConn is a conn over TLS, hertz uses it for ALPN.
+type tlsConn struct {
+	*conn
+
+	tc *tls.Conn
+}
+
+func newTLSConn(c *tls.Conn, readBufferSize int, idleClosed *atomic.Bool) *tlsConn {
+	return &tlsConn{conn: newConn(c, readBufferSize, idleClosed), tc: c}
+}
+
+func (c *tlsConn) Handshake() error {
+	return c.tc.Handshake()
+}
+
+func (c *tlsConn) ConnectionState() tls.ConnectionState {
+	return c.tc.ConnectionState()
+}
@@ -0,0 +1,61 @@
+package hertz
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	herrors "github.com/cloudwego/hertz/pkg/common/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnIdleClosed(t *testing.T) {
+	client, peer := net.Pipe()
+	defer peer.Close() //nolint:errcheck
+
+	idleClosed := &atomic.Bool{}
+	c := newConn(client, 4, idleClosed)
+
+	defer c.Close() //nolint:errcheck
+
+	idleClosed.Store(true)
+
+	// Waiting for the next request fails immediately.
+	require.NoError(t, c.SetReadTimeout(time.Minute))
+
+	_, err := c.Peek(1)
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	require.ErrorIs(t, c.ToHertzError(err), herrors.ErrTimeout)
+}
+
+func TestConnSenseDisconnect(t *testing.T) {
+	client, peer := net.Pipe()
+
+	c := newConn(client, 4, nil)
+	defer c.Close() //nolint:errcheck
+
+	// Hertz leaves its read timeout on the connection.
+	require.NoError(t, c.SetReadTimeout(50*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stop := c.senseDisconnect(cancel)
+
+	// The read timeout doesn't cancel the handler.
+	time.Sleep(150 * time.Millisecond)
+	require.NoError(t, ctx.Err())
+
+	require.NoError(t, peer.Close())
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the disconnect didn't cancel the context")
+	}
+
+	stop()
+}
@@ -0,0 +1,50 @@
+package hertz
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// NetworkUnix is the network name for unix domain sockets.
+const NetworkUnix = "unix"
+
+// isUnix returns whether the entrypoint listens on a unix domain socket.
+func (s *Server) isUnix() bool {
+	return s.config.Network == NetworkUnix
+}
+
+// removeStaleSocket removes a socket file left behind by a previous process.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if info.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("'%s' exists and is not a socket", path)
+	}
+
+	return os.Remove(path)
+}
+
+// removeSocket removes the socket file, it doesn't fail if it's already gone.
+func removeSocket(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// chmodSocket applies the configured mode to the socket file.
+func chmodSocket(path string, mode os.FileMode) error {
+	if err := os.Chmod(path, mode); err != nil {
+		return fmt.Errorf("while setting the mode of the socket '%s': %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package hertz
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// socketPath returns the path for a socket in a new temporary directory,
+// t.TempDir can exceed the length limit of socket paths.
+func socketPath(t *testing.T) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "orb")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) }) //nolint:errcheck
+
+	return filepath.Join(dir, "hertz.sock")
+}
+
+func TestUnixSocket(t *testing.T) {
+	path := socketPath(t)
+
+	srv := newTestServer(t,
+		WithNetwork(NetworkUnix),
+		WithAddress(path),
+		WithSocketMode(0o600),
+		WithInsecure(),
+		withPing(),
+	)
+
+	ctx := context.Background()
+	require.NoError(t, srv.Start(ctx))
+
+	require.Equal(t, path, srv.Address())
+	require.Equal(t, NetworkUnix, srv.Network())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NotZero(t, info.Mode()&fs.ModeSocket)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	resp, err := httpClient(srv).Get(serverURL(srv, pingPath))
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "pong", string(body))
+
+	require.NoError(t, srv.Stop(ctx))
+
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestUnixStaleSocket(t *testing.T) {
+	path := socketPath(t)
+
+	// A socket left behind by a crashed process.
+	ln, err := net.Listen(NetworkUnix, path)
+	require.NoError(t, err)
+
+	ul, ok := ln.(*net.UnixListener)
+	require.True(t, ok)
+	ul.SetUnlinkOnClose(false)
+	require.NoError(t, ln.Close())
+
+	srv := setupServer(t, WithNetwork(NetworkUnix), WithAddress(path), WithInsecure(), withPing())
+
+	resp, err := httpClient(srv).Get(serverURL(srv, pingPath))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestUnixNotASocket(t *testing.T) {
+	path := socketPath(t)
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+
+	srv := newTestServer(t, WithNetwork(NetworkUnix), WithAddress(path), WithInsecure())
+	require.Error(t, srv.Start(context.Background()))
+
+	// The file stays untouched.
+	data, err := os.ReadFile(path) //nolint:gosec
+	require.NoError(t, err)
+	require.Equal(t, "data", string(data))
+}